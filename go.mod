@@ -2,4 +2,4 @@ module github.com/lucaengelhard/lang
 
 go 1.25.7
 
-require github.com/sanity-io/litter v1.5.8 // indirect
+require github.com/sanity-io/litter v1.5.8
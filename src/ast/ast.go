@@ -1,18 +1,37 @@
 package ast
 
+import "github.com/lucaengelhard/lang/src/lexer"
+
 type Stmt interface {
 	stmt()
+	Pos() Position
 }
 
 type Expr interface {
 	expr()
+	Pos() Position
 }
 
+// Position is the span of a node in the source
 type Position struct {
+	// Byte offsets of the first byte and of the byte after the node
 	Start int
 	End   int
+	// 1-based line and column of the first byte
+	Line   int
+	Column int
+}
+
+func (pos Position) Pos() Position {
+	return pos
+}
+
+// To returns the span from the start of pos to the end of end
+func (pos Position) To(end Position) Position {
+	pos.End = end.End
+	return pos
 }
 
-func CreatePosition(start int, end int) Position {
-	return Position{Start: start, End: end}
+func TokenPosition(token lexer.Token) Position {
+	return Position{Start: token.Position, End: token.End, Line: token.Line, Column: token.Col}
 }
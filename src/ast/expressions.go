@@ -37,6 +37,16 @@ type StringExpr struct {
 
 func (n StringExpr) expr() {}
 
+// A string with embedded expressions. Parts holds the literal text around
+// them, so it has one element more than Expressions.
+type StringTemplateExpr struct {
+	Parts       []string
+	Expressions []Expr
+	Position
+}
+
+func (n StringTemplateExpr) expr() {}
+
 type SymbolExpr struct {
 	Value       string
 	IsReference bool
@@ -52,9 +62,29 @@ type DerefExpr struct {
 
 func (n DerefExpr) expr() {}
 
-type UnknowPrimary struct{ Position }
+// Stands in for an expression that could not be parsed
+type BadExpr struct{ Position }
+
+func (n BadExpr) expr() {}
 
-func (n UnknowPrimary) expr() {}
+// An if with an expression in both branches, it evaluates to the taken branch
+type IfExpr struct {
+	Condition Expr
+	True      Expr
+	False     Expr
+	Position
+}
+
+func (n IfExpr) expr() {}
+
+// A switch whose arms are all expressions, it evaluates to the taken arm
+type SwitchExpr struct {
+	Subject Expr
+	Arms    []SwitchArm
+	Position
+}
+
+func (n SwitchExpr) expr() {}
 
 type BinaryExpr struct {
 	Left     Expr
@@ -82,14 +112,66 @@ type AssignmentExpr struct {
 
 func (n AssignmentExpr) expr() {}
 
+// Access of a member of Assignee, like a.b
 type ChainExpr struct {
 	Assignee Expr
-	Member   Expr
+	Member   string
 	Position
 }
 
 func (n ChainExpr) expr() {}
 
+// Access of an element of Assignee, like a[i]
+type IndexExpr struct {
+	Assignee Expr
+	Index    Expr
+	Position
+}
+
+func (n IndexExpr) expr() {}
+
+// a[Start:End], Start and End are nil if they are left out
+type SliceExpr struct {
+	Assignee Expr
+	Start    Expr
+	End      Expr
+	Position
+}
+
+func (n SliceExpr) expr() {}
+
+// Returns the name of a symbol or a chain of symbols as written in the source, "" for other expressions
+func ExprName(expr Expr) string {
+	switch expr := expr.(type) {
+	case SymbolExpr:
+		return expr.Value
+	case ChainExpr:
+		if assignee := ExprName(expr.Assignee); assignee != "" {
+			return assignee + "." + expr.Member
+		}
+	}
+
+	return ""
+}
+
+// Returns the symbol a chain of member and index accesses starts at and the
+// accesses (ChainExpr or IndexExpr) in order. ok is false if the chain
+// doesn't start at a symbol.
+func AccessPath(expr Expr) (root SymbolExpr, path []Expr, ok bool) {
+	switch expr := expr.(type) {
+	case SymbolExpr:
+		return expr, nil, true
+	case ChainExpr:
+		root, path, ok = AccessPath(expr.Assignee)
+		return root, append(path, expr), ok
+	case IndexExpr:
+		root, path, ok = AccessPath(expr.Assignee)
+		return root, append(path, expr), ok
+	}
+
+	return SymbolExpr{}, nil, false
+}
+
 type StructInstantiationExpr struct {
 	StructIdentifier string
 	Properties       map[string]Expr
@@ -98,6 +180,20 @@ type StructInstantiationExpr struct {
 
 func (n StructInstantiationExpr) expr() {}
 
+type RecordProperty struct {
+	Name  string
+	Value Expr
+	Position
+}
+
+// An anonymous record like {a: 1, b: "abc"}, properties are in source order
+type RecordInstantiationExpr struct {
+	Properties []RecordProperty
+	Position
+}
+
+func (n RecordInstantiationExpr) expr() {}
+
 type ArrayInstantiationExpr struct {
 	Elements []Expr
 	Position
@@ -114,9 +210,32 @@ type FnCallArg struct {
 type FnCallExpr struct {
 	Caller    Expr
 	Arguments []FnCallArg
+	// Filled in by the typechecker, shared by every copy of the call
+	Binding *CallBinding
 	Position
 }
 
+// How the arguments of a call bind to the parameters of the function
+type CallBinding struct {
+	// Unset for calls that weren't checked or don't bind by name
+	Resolved bool
+	// Receivers of member calls are the first argument
+	Receiver  bool
+	Arguments []ArgumentBinding
+}
+
+type ArgumentBinding struct {
+	Param string
+	// Index of the argument in the call
+	Argument int
+	// Set for records spread into named arguments
+	Field string
+	// Index into arrays spread over positional parameters, -1 for other arguments
+	Element int
+	// Set for elements whose type is only known at runtime
+	Expected *Type
+}
+
 func (n FnCallExpr) expr() {}
 
 type FnArg struct {
@@ -0,0 +1,48 @@
+package ast
+
+// Patterns are the left hand side of switch arms
+type Pattern interface {
+	Pos() Position
+	pattern()
+}
+
+// Matches values equal to a literal: 1, "a", true
+type ValuePattern struct {
+	Value Expr
+	Position
+}
+
+func (n ValuePattern) pattern() {}
+
+// Matches if the condition is true: a < 2
+type ConditionPattern struct {
+	Condition Expr
+	Position
+}
+
+func (n ConditionPattern) pattern() {}
+
+// Either a type the value must have, or a variable that captures any value.
+// Which one is decided by whether a type with that name exists.
+type NamePattern struct {
+	Name string
+	Position
+}
+
+func (n NamePattern) pattern() {}
+
+// Matches structs and interfaces by type and their fields by pattern: Bar{a, b: 1}
+type DestructurePattern struct {
+	Type   string
+	Fields []FieldPattern
+	Position
+}
+
+func (n DestructurePattern) pattern() {}
+
+// A field of a destructure pattern, the shorthand Bar{a} is Bar{a: a}
+type FieldPattern struct {
+	Name    string
+	Pattern Pattern
+	Position
+}
@@ -55,9 +55,16 @@ type InterfaceStmt struct {
 
 func (n InterfaceStmt) stmt() {}
 
+// Value is always set, elements without an explicit value get it assigned by the parser
+type EnumElement struct {
+	Name  string
+	Value Expr
+	Position
+}
+
 type EnumStmt struct {
 	Identifier string
-	Elements   map[string]int
+	Elements   []EnumElement
 	Position
 }
 
@@ -72,7 +79,27 @@ type IfStmt struct {
 
 func (n IfStmt) stmt() {}
 
+type SwitchStmt struct {
+	Subject Expr
+	Arms    []SwitchArm
+	Position
+}
+
+func (n SwitchStmt) stmt() {}
+
+// An arm is taken if its pattern and guard match. The body is a block or an
+// expression statement.
+type SwitchArm struct {
+	Pattern Pattern
+	// nil if the arm has no guard
+	Guard Expr
+	Body  Stmt
+	Position
+}
+
+// Loops can be labeled (outer: while (...)) so that break and continue can target them
 type WhileStmt struct {
+	Label     string
 	Condition Expr
 	Body      BlockStmt
 	Position
@@ -81,6 +108,7 @@ type WhileStmt struct {
 func (n WhileStmt) stmt() {}
 
 type ForStmt struct {
+	Label      string
 	Assignment Stmt
 	Condition  Stmt
 	Increment  Expr
@@ -90,6 +118,18 @@ type ForStmt struct {
 
 func (n ForStmt) stmt() {}
 
+// for (Element, Index in Iterable), Index is empty if it isn't bound
+type ForInStmt struct {
+	Label    string
+	Element  string
+	Index    string
+	Iterable Expr
+	Body     BlockStmt
+	Position
+}
+
+func (n ForInStmt) stmt() {}
+
 type ReturnStmt struct {
 	Value Expr
 	Position
@@ -97,18 +137,28 @@ type ReturnStmt struct {
 
 func (n ReturnStmt) stmt() {}
 
+// Label is empty if the innermost loop is targeted
 type ContinueStmt struct {
+	Label string
 	Position
 }
 
 func (n ContinueStmt) stmt() {}
 
 type BreakStmt struct {
+	Label string
 	Position
 }
 
 func (n BreakStmt) stmt() {}
 
+// Stands in for the tokens skipped while recovering from a syntax error
+type BadStmt struct {
+	Position
+}
+
+func (n BadStmt) stmt() {}
+
 type ImportStmt struct {
 	Identifier string
 	Items      []string
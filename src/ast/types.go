@@ -24,6 +24,9 @@ const (
 	ARRAY           = "Array"
 	STRUCT          = "Struct"
 	DICT            = "Dict"
+	ENUM            = "Enum"
+	ANY             = "any"
+	VARIADIC        = "Variadic"
 )
 
 func CreateUnsetType() Type {
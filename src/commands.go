@@ -0,0 +1,279 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/lucaengelhard/lang/src/ast"
+	"github.com/lucaengelhard/lang/src/errorhandling"
+	"github.com/lucaengelhard/lang/src/interpreter"
+	"github.com/lucaengelhard/lang/src/lexer"
+	"github.com/lucaengelhard/lang/src/parser"
+	"github.com/lucaengelhard/lang/src/repl"
+	"github.com/lucaengelhard/lang/src/typechecker"
+	"github.com/sanity-io/litter"
+)
+
+const (
+	exit_ok     = 0
+	exit_errors = 1
+	exit_usage  = 2
+)
+
+type command struct {
+	description string
+	run         func(args []string) int
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"run":   {description: "Typecheck and interpret a file", run: run_cmd},
+		"check": {description: "Typecheck a file without running it", run: check_cmd},
+		"lex":   {description: "Print the tokens of a file", run: lex_cmd},
+		"parse": {description: "Print the abstract syntax tree of a file", run: parse_cmd},
+		"repl":  {description: "Start an interactive session", run: repl_cmd},
+	}
+}
+
+// The flags and the positional file argument of a subcommand
+type cli struct {
+	name       string
+	positional string
+	flags      *flag.FlagSet
+	format     string
+	path       string
+	// Where errors are reported to. Commands that dump to stdout report to stderr.
+	diagnostics *os.File
+}
+
+func create_cli(name string, positional string) *cli {
+	c := &cli{name: name, positional: positional, diagnostics: os.Stdout}
+	c.flags = flag.NewFlagSet(name, flag.ContinueOnError)
+	c.flags.StringVar(&c.format, "diagnostics-format", string(errorhandling.TEXT), fmt.Sprintf("format of reported errors %v", errorhandling.Formats))
+	c.flags.Usage = func() {
+		fmt.Fprintf(c.flags.Output(), "Usage: lang %s [flags] %s\n", name, positional)
+		fmt.Fprintf(c.flags.Output(), "\n%s\n", commands[name].description)
+		c.flags.PrintDefaults()
+	}
+
+	return c
+}
+
+// Parses the arguments and returns the single positional file argument
+func (c *cli) parse(args []string) (string, bool) {
+	if err := c.flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			os.Exit(exit_ok)
+		}
+		return "", false
+	}
+
+	if _, err := errorhandling.ParseFormat(c.format); err != nil {
+		fmt.Fprintf(c.flags.Output(), "lang %s: %v\n", c.name, err)
+		return "", false
+	}
+
+	if c.flags.NArg() != 1 {
+		fmt.Fprintf(c.flags.Output(), "lang %s: expected exactly one %s, got %d\n\n", c.name, c.positional, c.flags.NArg())
+		c.flags.Usage()
+		return "", false
+	}
+
+	c.path = c.flags.Arg(0)
+	return c.path, true
+}
+
+// Writes the errors in the requested format and returns the exit status.
+// SARIF logs are written even without errors, so consumers always get a valid file.
+func (c *cli) report(source string, errors []errorhandling.Error) int {
+	options := errorhandling.DefaultOptions()
+	options.Format, _ = errorhandling.ParseFormat(c.format)
+	options.Path = c.path
+	options.Color = options.Color && c.diagnostics == os.Stdout
+
+	if len(errors) > 0 || options.Format == errorhandling.SARIF {
+		errorhandling.WriteErrors(c.diagnostics, source, errors, options)
+	}
+
+	if len(errors) > 0 {
+		return exit_errors
+	}
+
+	return exit_ok
+}
+
+func read_source(path string) (string, bool) {
+	bytes, err := os.ReadFile(path)
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "lang: file %s does not exist\n", path)
+		} else {
+			fmt.Fprintf(os.Stderr, "lang: cannot read %s: %v\n", path, err)
+		}
+		return "", false
+	}
+
+	return string(bytes), true
+}
+
+type stage int
+
+const (
+	stage_lex stage = iota
+	stage_parse
+	stage_check
+)
+
+// Runs the frontend on a source up to (and including) the given stage.
+// Every stage only runs if the previous ones didn't produce any errors.
+func compile(source string, until stage) ([]lexer.Token, ast.Stmt, []errorhandling.Error) {
+	tokens, errors := lexer.Tokenize(source)
+
+	if len(errors) > 0 || until == stage_lex {
+		return tokens, nil, errors
+	}
+
+	abstract_syntax_tree, parser_errors := parser.Parse(tokens)
+	errors = append(errors, parser_errors...)
+
+	if len(errors) > 0 || until == stage_parse {
+		return tokens, abstract_syntax_tree, errors
+	}
+
+	type_errors := typechecker.Init(abstract_syntax_tree)
+	errors = append(errors, type_errors...)
+
+	return tokens, abstract_syntax_tree, errors
+}
+
+func run_cmd(args []string) int {
+	c := create_cli("run", "<file>")
+	// The program writes to stdout itself
+	c.diagnostics = os.Stderr
+	skip_check := c.flags.Bool("skip-check", false, "interpret the file without typechecking it first")
+
+	path, ok := c.parse(args)
+	if !ok {
+		return exit_usage
+	}
+
+	source, ok := read_source(path)
+	if !ok {
+		return exit_errors
+	}
+
+	until := stage_check
+	if *skip_check {
+		until = stage_parse
+	}
+
+	_, abstract_syntax_tree, errors := compile(source, until)
+
+	if len(errors) > 0 {
+		return c.report(source, errors)
+	}
+
+	return c.report(source, interpreter.Init(abstract_syntax_tree))
+}
+
+func check_cmd(args []string) int {
+	c := create_cli("check", "<file>")
+	quiet := c.flags.Bool("q", false, "don't print anything, only set the exit status")
+
+	path, ok := c.parse(args)
+	if !ok {
+		return exit_usage
+	}
+
+	source, ok := read_source(path)
+	if !ok {
+		return exit_errors
+	}
+
+	_, _, errors := compile(source, stage_check)
+
+	if *quiet {
+		if len(errors) > 0 {
+			return exit_errors
+		}
+		return exit_ok
+	}
+
+	return c.report(source, errors)
+}
+
+func lex_cmd(args []string) int {
+	c := create_cli("lex", "<file>")
+	c.diagnostics = os.Stderr
+
+	path, ok := c.parse(args)
+	if !ok {
+		return exit_usage
+	}
+
+	source, ok := read_source(path)
+	if !ok {
+		return exit_errors
+	}
+
+	tokens, _, errors := compile(source, stage_lex)
+	lexer.PrintTokens(tokens)
+
+	return c.report(source, errors)
+}
+
+func parse_cmd(args []string) int {
+	c := create_cli("parse", "<file>")
+	c.diagnostics = os.Stderr
+	check := c.flags.Bool("check", false, "typecheck the tree before printing it")
+
+	path, ok := c.parse(args)
+	if !ok {
+		return exit_usage
+	}
+
+	source, ok := read_source(path)
+	if !ok {
+		return exit_errors
+	}
+
+	until := stage_parse
+	if *check {
+		until = stage_check
+	}
+
+	_, abstract_syntax_tree, errors := compile(source, until)
+
+	if abstract_syntax_tree != nil {
+		litter.Dump(abstract_syntax_tree)
+	}
+
+	return c.report(source, errors)
+}
+
+func repl_cmd(args []string) int {
+	flags := flag.NewFlagSet("repl", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: lang repl")
+		fmt.Fprintf(flags.Output(), "\n%s\n", commands["repl"].description)
+	}
+
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exit_ok
+		}
+		return exit_usage
+	}
+
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return exit_usage
+	}
+
+	repl.Start(os.Stdin, os.Stdout)
+	return exit_ok
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The test binary runs the cli itself when it is started by lang()
+func TestMain(m *testing.M) {
+	if os.Getenv("LANG_TEST_CLI") == "1" {
+		main()
+		os.Exit(exit_ok)
+	}
+
+	os.Exit(m.Run())
+}
+
+type result struct {
+	stdout string
+	stderr string
+	status int
+}
+
+func lang(t *testing.T, args ...string) result {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), "LANG_TEST_CLI=1")
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	err := cmd.Run()
+	var exit *exec.ExitError
+	if err != nil && !errors.As(err, &exit) {
+		t.Fatalf("running lang %v: %v", args, err)
+	}
+
+	return result{stdout: stdout.String(), stderr: stderr.String(), status: cmd.ProcessState.ExitCode()}
+}
+
+func write_source(t *testing.T, source string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "main.lang")
+
+	if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func expect_status(t *testing.T, r result, status int) {
+	t.Helper()
+
+	if r.status != status {
+		t.Errorf("expected exit status %d, got %d (stdout %q, stderr %q)", status, r.status, r.stdout, r.stderr)
+	}
+}
+
+func TestRunWritesTheProgramOutputToStdout(t *testing.T) {
+	r := lang(t, "run", write_source(t, `println("hello");`))
+
+	expect_status(t, r, exit_ok)
+	if r.stdout != "hello\n" || r.stderr != "" {
+		t.Errorf("expected only the program output, got stdout %q and stderr %q", r.stdout, r.stderr)
+	}
+}
+
+func TestRunWritesErrorsToStderr(t *testing.T) {
+	r := lang(t, "run", write_source(t, `println("hello"); 1 / 0;`))
+
+	expect_status(t, r, exit_errors)
+	if r.stdout != "hello\n" {
+		t.Errorf("expected only the program output on stdout, got %q", r.stdout)
+	}
+	if !strings.Contains(r.stderr, "Integer division by zero") {
+		t.Errorf("expected the runtime error on stderr, got %q", r.stderr)
+	}
+}
+
+func TestRunKeepsMachineReadableReportsOutOfStdout(t *testing.T) {
+	r := lang(t, "run", "--diagnostics-format", "sarif", write_source(t, `println("hello");`))
+
+	expect_status(t, r, exit_ok)
+	if r.stdout != "hello\n" {
+		t.Errorf("expected only the program output on stdout, got %q", r.stdout)
+	}
+
+	var log map[string]any
+	if err := json.Unmarshal([]byte(r.stderr), &log); err != nil || log["version"] != "2.1.0" {
+		t.Errorf("expected a sarif log on stderr, got %q", r.stderr)
+	}
+
+	r = lang(t, "run", "--diagnostics-format", "json", write_source(t, `println("hello"); 1 / 0;`))
+
+	expect_status(t, r, exit_errors)
+	if r.stdout != "hello\n" {
+		t.Errorf("expected only the program output on stdout, got %q", r.stdout)
+	}
+
+	var diagnostic map[string]any
+	if err := json.Unmarshal([]byte(r.stderr), &diagnostic); err != nil || diagnostic["phase"] != "runtime" {
+		t.Errorf("expected a json diagnostic on stderr, got %q", r.stderr)
+	}
+}
+
+func TestCheckExitStatus(t *testing.T) {
+	expect_status(t, lang(t, "check", write_source(t, `let a: int = 1;`)), exit_ok)
+	expect_status(t, lang(t, "check", write_source(t, `let a: int = "x";`)), exit_errors)
+	expect_status(t, lang(t, "check", "-q", write_source(t, `let a: int = "x";`)), exit_errors)
+}
+
+func TestUsageErrors(t *testing.T) {
+	expect_status(t, lang(t), exit_usage)
+	expect_status(t, lang(t, "compile"), exit_usage)
+	expect_status(t, lang(t, "check"), exit_usage)
+	expect_status(t, lang(t, "check", "a.lang", "b.lang"), exit_usage)
+	expect_status(t, lang(t, "check", "--diagnostics-format", "xml", "a.lang"), exit_usage)
+}
+
+func TestMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.lang")
+	r := lang(t, "run", path)
+
+	expect_status(t, r, exit_errors)
+	if r.stderr != "lang: file "+path+" does not exist\n" {
+		t.Errorf("expected a message about the missing file, got %q", r.stderr)
+	}
+}
+
+func TestDumpsGoToStdout(t *testing.T) {
+	path := write_source(t, `let a = 1;`)
+
+	r := lang(t, "lex", path)
+	expect_status(t, r, exit_ok)
+	if !strings.Contains(r.stdout, "let -> let") || r.stderr != "" {
+		t.Errorf("expected the tokens on stdout, got stdout %q and stderr %q", r.stdout, r.stderr)
+	}
+
+	r = lang(t, "parse", path)
+	expect_status(t, r, exit_ok)
+	if !strings.Contains(r.stdout, "DeclarationStmt") || r.stderr != "" {
+		t.Errorf("expected the tree on stdout, got stdout %q and stderr %q", r.stdout, r.stderr)
+	}
+}
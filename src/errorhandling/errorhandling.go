@@ -2,24 +2,122 @@ package errorhandling
 
 import (
 	"fmt"
+	"io"
+	"os"
+)
+
+type Phase string
+
+const (
+	LEXER   Phase = "lexer"
+	PARSER  Phase = "parser"
+	TYPE    Phase = "type"
+	RUNTIME Phase = "runtime"
+)
 
-	"github.com/lucaengelhard/lang/src/lib"
+var phase_prefix_lookup = map[Phase]string{
+	LEXER:   "Lexer error -> ",
+	PARSER:  "Parser error -> ",
+	TYPE:    "Type error -> ",
+	RUNTIME: "Runtime error -> ",
+}
+
+type Severity string
+
+const (
+	ERROR   Severity = "error"
+	WARNING Severity = "warning"
 )
 
 type Error struct {
-	Message      string
+	Message string
+	Phase   Phase
+	// Defaults to ERROR when unset
+	Severity Severity
+	// Byte offsets of the start and the end (exclusive) of the erroneous source.
+	// An End before Position means the error only points at Position.
 	Position     int
+	End          int
 	TokenLiteral string
+	// Secondary locations related to the error, e.g. the declaration of a variable
+	Labels []Label
+	Notes  []string
+	// Function calls that were active when a runtime error occurred, innermost first
+	Stack []StackFrame
+}
+
+func (err Error) GetSeverity() Severity {
+	if err.Severity == "" {
+		return ERROR
+	}
+
+	return err.Severity
+}
+
+type Label struct {
+	Position int
+	End      int
+	Message  string
+}
+
+type StackFrame struct {
+	Name string
+	// Position of the call
+	Position int
+}
+
+type Format string
+
+const (
+	TEXT  Format = "text"
+	JSON  Format = "json"
+	SARIF Format = "sarif"
+)
+
+var Formats = []Format{TEXT, JSON, SARIF}
+
+func ParseFormat(name string) (Format, error) {
+	for _, format := range Formats {
+		if string(format) == name {
+			return format, nil
+		}
+	}
+
+	return TEXT, fmt.Errorf("unknown diagnostics format %q (expected one of %v)", name, Formats)
+}
+
+type Options struct {
+	Format Format
+	// Path of the source file, reported by the machine readable formats
+	Path string
+	// Use ANSI escape codes to highlight the text output
+	Color bool
+}
+
+func DefaultOptions() Options {
+	return Options{Format: TEXT, Color: is_terminal(os.Stdout) && os.Getenv("NO_COLOR") == ""}
 }
 
 func PrintErrors(source string, errors []Error) {
-	for _, err := range errors {
-		row, col := lib.Int_to_file_pos(source, err.Position)
-		print_error(err.Message, row, col)
+	WriteErrors(os.Stdout, source, errors, DefaultOptions())
+}
+
+func WriteErrors(out io.Writer, source string, errors []Error, options Options) {
+	switch options.Format {
+	case JSON:
+		write_json(out, source, errors, options)
+	case SARIF:
+		write_sarif(out, source, errors, options)
+	default:
+		r := create_renderer(out, source, options)
 
+		for _, err := range errors {
+			r.render(err)
+		}
 	}
 }
 
-func print_error(message string, row int, col int) {
-	fmt.Printf("[%v:%v]: %s\n", row, col, message)
+func is_terminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
 }
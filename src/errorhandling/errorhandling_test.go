@@ -0,0 +1,32 @@
+package errorhandling_test
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// Compares the output with testdata/name, run with -update to rewrite it
+func expect_golden(t *testing.T, name string, output []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+
+	if *update {
+		if err := os.WriteFile(path, output, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(output, expected) {
+		t.Errorf("output doesn't match %s\n--- got\n%s\n--- expected\n%s", path, output, expected)
+	}
+}
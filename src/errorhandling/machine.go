@@ -0,0 +1,224 @@
+package errorhandling
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/lucaengelhard/lang/src/lib"
+)
+
+type json_location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Offset int `json:"offset"`
+}
+
+type json_label struct {
+	Message string        `json:"message"`
+	Start   json_location `json:"start"`
+	End     json_location `json:"end"`
+}
+
+type json_frame struct {
+	Name  string        `json:"name"`
+	Start json_location `json:"start"`
+}
+
+type json_error struct {
+	Phase    Phase         `json:"phase"`
+	Severity Severity      `json:"severity"`
+	Message  string        `json:"message"`
+	File     string        `json:"file,omitempty"`
+	Start    json_location `json:"start"`
+	End      json_location `json:"end"`
+	Token    string        `json:"token,omitempty"`
+	Labels   []json_label  `json:"labels,omitempty"`
+	Notes    []string      `json:"notes,omitempty"`
+	Stack    []json_frame  `json:"stack,omitempty"`
+}
+
+func locate(lines lib.LineTable, offset int) json_location {
+	line, col := lines.Locate(offset)
+	return json_location{Line: line, Column: col, Offset: offset}
+}
+
+// Errors without a span are treated as spanning a single byte
+func error_end(start int, end int) int {
+	return max(end, start+1)
+}
+
+// Only lexer and parser errors know their token, the token of other errors
+// is the source they span
+func token_literal(source string, err Error) string {
+	if err.TokenLiteral != "" || err.Position < 0 || err.End <= err.Position || err.End > len(source) {
+		return err.TokenLiteral
+	}
+
+	return source[err.Position:err.End]
+}
+
+// Writes one JSON object per line and error
+func write_json(out io.Writer, source string, errors []Error, options Options) {
+	lines := lib.NewLineTable(source)
+	encoder := json.NewEncoder(out)
+	encoder.SetEscapeHTML(false)
+
+	for _, err := range errors {
+		labels := make([]json_label, 0, len(err.Labels))
+		for _, label := range err.Labels {
+			labels = append(labels, json_label{
+				Message: clean_message(label.Message),
+				Start:   locate(lines, label.Position),
+				End:     locate(lines, error_end(label.Position, label.End)),
+			})
+		}
+
+		stack := make([]json_frame, 0, len(err.Stack))
+		for _, frame := range err.Stack {
+			stack = append(stack, json_frame{Name: frame.Name, Start: locate(lines, frame.Position)})
+		}
+
+		encoder.Encode(json_error{
+			Phase:    err.Phase,
+			Severity: err.GetSeverity(),
+			Message:  clean_message(err.Message),
+			File:     options.Path,
+			Start:    locate(lines, err.Position),
+			End:      locate(lines, error_end(err.Position, err.End)),
+			Token:    token_literal(source, err),
+			Labels:   labels,
+			Notes:    err.Notes,
+			Stack:    stack,
+		})
+	}
+}
+
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+
+const sarif_schema = "https://json.schemastore.org/sarif-2.1.0.json"
+
+type sarif_log struct {
+	Schema  string      `json:"$schema"`
+	Version string      `json:"version"`
+	Runs    []sarif_run `json:"runs"`
+}
+
+type sarif_run struct {
+	Tool       sarif_tool     `json:"tool"`
+	ColumnKind string         `json:"columnKind"`
+	Results    []sarif_result `json:"results"`
+}
+
+type sarif_tool struct {
+	Driver sarif_driver `json:"driver"`
+}
+
+type sarif_driver struct {
+	Name  string       `json:"name"`
+	Rules []sarif_rule `json:"rules"`
+}
+
+type sarif_rule struct {
+	Id               string        `json:"id"`
+	ShortDescription sarif_message `json:"shortDescription"`
+}
+
+type sarif_message struct {
+	Text string `json:"text"`
+}
+
+type sarif_result struct {
+	RuleId           string            `json:"ruleId"`
+	Level            Severity          `json:"level"`
+	Message          sarif_message     `json:"message"`
+	Locations        []sarif_location  `json:"locations"`
+	RelatedLocations []sarif_location  `json:"relatedLocations,omitempty"`
+	Properties       map[string]string `json:"properties,omitempty"`
+}
+
+type sarif_location struct {
+	PhysicalLocation sarif_physical_location `json:"physicalLocation"`
+	Message          *sarif_message          `json:"message,omitempty"`
+}
+
+type sarif_physical_location struct {
+	ArtifactLocation sarif_artifact_location `json:"artifactLocation"`
+	Region           sarif_region            `json:"region"`
+}
+
+type sarif_artifact_location struct {
+	Uri string `json:"uri,omitempty"`
+}
+
+type sarif_region struct {
+	StartLine   int            `json:"startLine"`
+	StartColumn int            `json:"startColumn"`
+	EndLine     int            `json:"endLine"`
+	EndColumn   int            `json:"endColumn"`
+	Snippet     *sarif_message `json:"snippet,omitempty"`
+}
+
+var sarif_rules = []sarif_rule{
+	{Id: string(LEXER), ShortDescription: sarif_message{Text: "Lexer error"}},
+	{Id: string(PARSER), ShortDescription: sarif_message{Text: "Parser error"}},
+	{Id: string(TYPE), ShortDescription: sarif_message{Text: "Type error"}},
+	{Id: string(RUNTIME), ShortDescription: sarif_message{Text: "Runtime error"}},
+}
+
+func sarif_location_of(lines lib.LineTable, path string, start int, end int, snippet string) sarif_location {
+	start_line, start_col := lines.Locate(start)
+	end_line, end_col := lines.Locate(error_end(start, end))
+
+	location := sarif_location{PhysicalLocation: sarif_physical_location{
+		ArtifactLocation: sarif_artifact_location{Uri: path},
+		Region: sarif_region{
+			StartLine:   start_line,
+			StartColumn: start_col,
+			EndLine:     end_line,
+			EndColumn:   end_col,
+		},
+	}}
+
+	if snippet != "" {
+		location.PhysicalLocation.Region.Snippet = &sarif_message{Text: snippet}
+	}
+
+	return location
+}
+
+// Writes a single SARIF log containing all errors
+func write_sarif(out io.Writer, source string, errors []Error, options Options) {
+	lines := lib.NewLineTable(source)
+	results := make([]sarif_result, 0, len(errors))
+
+	for _, err := range errors {
+		related := make([]sarif_location, 0, len(err.Labels))
+		for _, label := range err.Labels {
+			location := sarif_location_of(lines, options.Path, label.Position, label.End, "")
+			location.Message = &sarif_message{Text: clean_message(label.Message)}
+			related = append(related, location)
+		}
+
+		results = append(results, sarif_result{
+			RuleId:           string(err.Phase),
+			Level:            err.GetSeverity(),
+			Message:          sarif_message{Text: clean_message(err.Message)},
+			Locations:        []sarif_location{sarif_location_of(lines, options.Path, err.Position, err.End, token_literal(source, err))},
+			RelatedLocations: related,
+			Properties:       map[string]string{"phase": string(err.Phase)},
+		})
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(sarif_log{
+		Schema:  sarif_schema,
+		Version: "2.1.0",
+		Runs: []sarif_run{{
+			Tool:       sarif_tool{Driver: sarif_driver{Name: "lang", Rules: sarif_rules}},
+			ColumnKind: "unicodeCodePoints",
+			Results:    results,
+		}},
+	})
+}
@@ -0,0 +1,39 @@
+package errorhandling_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lucaengelhard/lang/src/errorhandling"
+)
+
+// é is two bytes but one column
+const machine_source = "let é = 1;\nlet b: int = \"abc\";\n"
+
+var machine_errors = []errorhandling.Error{
+	{
+		Message:  "Type string doesn't match int",
+		Phase:    errorhandling.TYPE,
+		Position: 25,
+		End:      30,
+		Labels:   []errorhandling.Label{{Position: 4, End: 6, Message: "declared here"}},
+		Notes:    []string{"strings can't be converted"},
+	},
+	{Message: "Unexpected token", Phase: errorhandling.PARSER, Position: 9, End: 10, TokenLiteral: "1"},
+	{Message: "Integer division by zero", Phase: errorhandling.RUNTIME, Position: 10, End: 0, Stack: []errorhandling.StackFrame{{Name: "f", Position: 10}}},
+	{Message: "Spans two lines", Phase: errorhandling.TYPE, Severity: errorhandling.WARNING, Position: 4, End: 15},
+}
+
+func write(format errorhandling.Format) []byte {
+	var out bytes.Buffer
+	errorhandling.WriteErrors(&out, machine_source, machine_errors, errorhandling.Options{Format: format, Path: "main.lang"})
+	return out.Bytes()
+}
+
+func TestJSONLines(t *testing.T) {
+	expect_golden(t, "errors.jsonl", write(errorhandling.JSON))
+}
+
+func TestSARIF(t *testing.T) {
+	expect_golden(t, "errors.sarif", write(errorhandling.SARIF))
+}
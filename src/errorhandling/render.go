@@ -0,0 +1,202 @@
+package errorhandling
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/lucaengelhard/lang/src/lib"
+)
+
+const (
+	ansi_reset = "\033[0m"
+	ansi_bold  = "\033[1m"
+	ansi_red   = "\033[31m"
+	ansi_blue  = "\033[34m"
+	ansi_cyan  = "\033[36m"
+)
+
+const tab_width = 4
+
+type renderer struct {
+	out     io.Writer
+	source  string
+	lines   lib.LineTable
+	options Options
+}
+
+// An underlined span of a single line of the snippet
+type annotation struct {
+	line    int
+	start   int
+	end     int
+	message string
+	primary bool
+}
+
+func create_renderer(out io.Writer, source string, options Options) *renderer {
+	return &renderer{out: out, source: source, lines: lib.NewLineTable(source), options: options}
+}
+
+func (r *renderer) paint(text string, codes ...string) string {
+	if !r.options.Color || text == "" {
+		return text
+	}
+
+	return strings.Join(codes, "") + text + ansi_reset
+}
+
+// Renders an error in the form
+//
+//	[row:col]: message
+//	  |
+//	1 | let a: int = "abc";
+//	  |              ^^^^^
+//	  = note: ...
+func (r *renderer) render(err Error) {
+	row, col := r.lines.Locate(err.Position)
+	fmt.Fprintf(r.out, "%s %s\n", r.paint(fmt.Sprintf("[%v:%v]:", row, col), ansi_bold, ansi_red), r.paint(phase_prefix_lookup[err.Phase]+clean_message(err.Message), ansi_bold))
+
+	annotations := []annotation{r.annotate(err.Position, err.End, "", true)}
+	for _, label := range err.Labels {
+		annotations = append(annotations, r.annotate(label.Position, label.End, label.Message, false))
+	}
+
+	r.render_snippet(annotations)
+
+	gutter := strings.Repeat(" ", r.gutter_width(annotations))
+	for _, note := range err.Notes {
+		fmt.Fprintf(r.out, "%s %s %s\n", gutter, r.paint("=", ansi_bold, ansi_blue), "note: "+clean_message(note))
+	}
+
+	for _, frame := range err.Stack {
+		row, col := r.lines.Locate(frame.Position)
+		fmt.Fprintf(r.out, "    in %s called at [%v:%v]\n", frame.Name, row, col)
+	}
+}
+
+func (r *renderer) annotate(start int, end int, message string, primary bool) annotation {
+	start = min(max(start, 0), len(r.source))
+	line, _ := r.lines.Locate(start)
+	line_end := r.line_end(line)
+
+	// Spans across several lines are only underlined on their first line
+	end = min(max(end, start+1), line_end)
+
+	return annotation{line: line, start: start, end: end, message: clean_message(message), primary: primary}
+}
+
+func (r *renderer) render_snippet(annotations []annotation) {
+	if len(r.source) == 0 {
+		return
+	}
+
+	slices.SortStableFunc(annotations, func(a, b annotation) int { return a.line - b.line })
+
+	width := r.gutter_width(annotations)
+	empty_gutter := r.paint(fmt.Sprintf("%*s |", width, ""), ansi_bold, ansi_blue)
+
+	fmt.Fprintln(r.out, empty_gutter)
+
+	for i, a := range annotations {
+		if i == 0 || annotations[i-1].line != a.line {
+			if i > 0 && a.line > annotations[i-1].line+1 {
+				fmt.Fprintln(r.out, r.paint(fmt.Sprintf("%*s", width+2, "..."), ansi_bold, ansi_blue))
+			}
+
+			line_start := r.lines.LineStart(a.line)
+			text := expand_tabs(r.source[line_start:r.line_end(a.line)])
+			fmt.Fprintf(r.out, "%s %s\n", r.paint(fmt.Sprintf("%*d |", width, a.line), ansi_bold, ansi_blue), text)
+		}
+
+		line_start := r.lines.LineStart(a.line)
+		offset := display_width(r.source[line_start:a.start])
+		length := max(display_width(r.source[a.start:a.end]), 1)
+
+		marker, color := "-", ansi_blue
+		if a.primary {
+			marker, color = "^", ansi_red
+		}
+
+		underline := strings.Repeat(marker, length)
+		if a.message != "" {
+			underline += " " + a.message
+		}
+
+		fmt.Fprintf(r.out, "%s %s%s\n", empty_gutter, strings.Repeat(" ", offset), r.paint(underline, ansi_bold, color))
+	}
+}
+
+func (r *renderer) gutter_width(annotations []annotation) int {
+	highest := 1
+	for _, a := range annotations {
+		highest = max(highest, a.line)
+	}
+
+	return len(fmt.Sprint(highest))
+}
+
+// Returns the offset of the line break (or the end of the source) of a line
+func (r *renderer) line_end(line int) int {
+	end := len(r.source)
+	if line < r.lines.LineCount() {
+		end = r.lines.LineStart(line+1) - 1
+	}
+
+	for end > r.lines.LineStart(line) && r.source[end-1] == '\r' {
+		end--
+	}
+
+	return end
+}
+
+func clean_message(message string) string {
+	return strings.TrimSpace(message)
+}
+
+func expand_tabs(text string) string {
+	return strings.ReplaceAll(text, "\t", strings.Repeat(" ", tab_width))
+}
+
+// Number of columns a text takes up on the terminal
+func display_width(text string) int {
+	var width = 0
+	for _, r := range text {
+		width += rune_width(r)
+	}
+
+	return width
+}
+
+// East Asian wide characters and emoji take up two columns, combining marks none
+func rune_width(r rune) int {
+	switch {
+	case r == '\t':
+		return tab_width
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case slices.ContainsFunc(wide_ranges, func(span [2]rune) bool { return span[0] <= r && r <= span[1] }):
+		return 2
+	}
+
+	return 1
+}
+
+var wide_ranges = [][2]rune{
+	{0x1100, 0x115F},   // Hangul Jamo
+	{0x2E80, 0x303E},   // CJK radicals and punctuation
+	{0x3041, 0x33FF},   // Kana, CJK symbols
+	{0x3400, 0x4DBF},   // CJK extension A
+	{0x4E00, 0x9FFF},   // CJK unified ideographs
+	{0xA000, 0xA4CF},   // Yi
+	{0xAC00, 0xD7A3},   // Hangul syllables
+	{0xF900, 0xFAFF},   // CJK compatibility ideographs
+	{0xFE30, 0xFE4F},   // CJK compatibility forms
+	{0xFF00, 0xFF60},   // Fullwidth forms
+	{0xFFE0, 0xFFE6},   // Fullwidth signs
+	{0x1F300, 0x1F64F}, // Pictographs and emoticons
+	{0x1F900, 0x1F9FF}, // Supplemental pictographs
+	{0x20000, 0x3FFFD}, // CJK extensions B and later
+}
@@ -0,0 +1,81 @@
+package errorhandling_test
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/lucaengelhard/lang/src/errorhandling"
+)
+
+type render_case struct {
+	name   string
+	source string
+	err    errorhandling.Error
+	color  bool
+}
+
+// Errors point at the first occurrence of at in the source
+func at(source string, text string) (int, int) {
+	start := strings.Index(source, text)
+	return start, start + len(text)
+}
+
+func span_error(source string, text string, message string) errorhandling.Error {
+	start, end := at(source, text)
+	return errorhandling.Error{Message: message, Phase: errorhandling.TYPE, Position: start, End: end}
+}
+
+func labeled(err errorhandling.Error, source string, text string, message string) errorhandling.Error {
+	start, end := at(source, text)
+	err.Labels = append(err.Labels, errorhandling.Label{Position: start, End: end, Message: message})
+	return err
+}
+
+func render_cases() []render_case {
+	simple := "let a: int = \"abc\";\n"
+	declared := "let a = 1;\n\n\nlet b: string = a;\n"
+	multiline := "let a = [\n\t1,\n\t2\n];\n"
+	tabs := "\tlet a:\tint = \"abc\";\n"
+	unicode := "let é = \"ü\" + 1;\n"
+	wide := "let 名前 = \"日本\" + 1;\n"
+	combining := "let e\u0301 = \"x\" + 1;\n"
+	crlf := "let a = 1;\r\nlet b: string = a;\r\n"
+	tenth := strings.Repeat("\n", 9) + "x;\n"
+
+	return []render_case{
+		{"simple", simple, span_error(simple, `"abc"`, "Type string doesn't match int"), false},
+		{"label on an earlier line", declared, labeled(span_error(declared, "a;", "Type int doesn't match string"), declared, "a = 1", "declared here"), false},
+		{"label on the same line", simple, labeled(span_error(simple, `"abc"`, "Type string doesn't match int"), simple, "int", "expected because of this"), false},
+		{"multi-line span", multiline, span_error(multiline, multiline[8:len(multiline)-2], "Spans several lines"), false},
+		{"tabs", tabs, span_error(tabs, `"abc"`, "Type string doesn't match int"), false},
+		{"unicode", unicode, span_error(unicode, `"ü" + 1`, "Can't add int to string"), false},
+		{"wide characters", wide, labeled(span_error(wide, `"日本" + 1`, "Can't add int to string"), wide, "名前", "declared here"), false},
+		{"combining marks", combining, span_error(combining, `"x" + 1`, "Can't add int to string"), false},
+		{"crlf", crlf, span_error(crlf, "a;", "Type int doesn't match string"), false},
+		{"empty span", simple, errorhandling.Error{Message: "Expected token semi_colon", Phase: errorhandling.PARSER, Position: len(simple) - 1, End: -1}, false},
+		{"end of the source", "let a", errorhandling.Error{Message: "Unexpected end of file", Phase: errorhandling.PARSER, Position: 5, End: 5}, false},
+		{"wide gutter", tenth, span_error(tenth, "x", "x doesn't exist"), false},
+		{"notes and stack", simple, errorhandling.Error{
+			Message:  "Integer division by zero",
+			Phase:    errorhandling.RUNTIME,
+			Position: 4,
+			End:      5,
+			Notes:    []string{"the divisor was 0"},
+			Stack:    []errorhandling.StackFrame{{Name: "f", Position: 13}},
+		}, false},
+		{"color", simple, labeled(span_error(simple, `"abc"`, "Type string doesn't match int"), simple, "int", "expected"), true},
+	}
+}
+
+func TestRenderer(t *testing.T) {
+	var out bytes.Buffer
+
+	for _, test := range render_cases() {
+		fmt.Fprintf(&out, "== %s\n", test.name)
+		errorhandling.WriteErrors(&out, test.source, []errorhandling.Error{test.err}, errorhandling.Options{Format: errorhandling.TEXT, Color: test.color})
+	}
+
+	expect_golden(t, "render.txt", out.Bytes())
+}
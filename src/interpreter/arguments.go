@@ -0,0 +1,97 @@
+package interpreter
+
+import (
+	"slices"
+
+	"github.com/lucaengelhard/lang/src/ast"
+)
+
+// Names every argument after the parameter the typechecker bound it to.
+// Records and arrays it spread are replaced by their fields and elements.
+func (scope *env) apply_binding(binding *ast.CallBinding, args []FnCallArg) []FnCallArg {
+	bound := make([]FnCallArg, 0, len(binding.Arguments))
+
+	for _, argument := range binding.Arguments {
+		arg := args[argument.Argument]
+
+		switch {
+		case argument.Field != "":
+			record, _ := arg.Value.(struct_value)
+			arg = FnCallArg{Value: record.Fields[argument.Field], Position: arg.Position}
+		case argument.Element >= 0:
+			arg = FnCallArg{Value: scope.spread_element(binding, argument, arg), Position: arg.Position}
+		}
+
+		arg.Identifier = argument.Param
+		bound = append(bound, arg)
+	}
+
+	return bound
+}
+
+// Only the elements of array literals are checked before the program runs
+func (scope *env) spread_element(binding *ast.CallBinding, argument ast.ArgumentBinding, arg FnCallArg) any {
+	array, _ := arg.Value.([]any)
+	covered := 0
+
+	for _, other := range binding.Arguments {
+		if other.Argument == argument.Argument {
+			covered++
+		}
+	}
+
+	if len(array) != covered {
+		scope.throw(arg.Position, "Array with %d elements is spread over %d arguments", len(array), covered)
+	}
+
+	element := array[argument.Element]
+	if argument.Expected != nil && !scope.value_is(element, *argument.Expected) {
+		scope.throw(arg.Position, "Element %d can't be passed as argument %s of type %s", argument.Element, argument.Param, argument.Expected.ToString())
+	}
+
+	return element
+}
+
+// Arguments of calls the typechecker didn't resolve are bound in order
+func (scope *env) bind_positional(params []ast.FnArg, args []FnCallArg) []FnCallArg {
+	bound := slices.Clone(args)
+
+	for index := range bound {
+		if bound[index].Identifier != "" {
+			continue
+		}
+
+		if index >= len(params) {
+			scope.throw(bound[index].Position, "Too many arguments. Expected %d, got %d", len(params), len(args))
+		}
+
+		bound[index].Identifier = params[index].Identifier
+	}
+
+	return bound
+}
+
+// The typechecker reports arguments that don't bind to exactly one
+// parameter, they are checked again for programs run without it
+func (scope *env) check_binding(params map[string]ast.FnArg, arg FnCallArg) ast.FnArg {
+	param, exists := params[arg.Identifier]
+
+	if !exists {
+		scope.throw(arg.Position, "Argument %s doesn't exist", arg.Identifier)
+	}
+
+	if _, bound := scope.Declarations[arg.Identifier]; bound {
+		scope.throw(arg.Position, "Argument %s is already bound", arg.Identifier)
+	}
+
+	return param
+}
+
+func (scope *env) check_missing(params []ast.FnArg) {
+	for _, param := range params {
+		if _, bound := scope.Declarations[param.Identifier]; !bound {
+			frames := scope.get_root().frames
+			scope.throw(frames[len(frames)-1].Position, "Missing argument %s", param.Identifier)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package interpreter_test
+
+import (
+	"testing"
+
+	"github.com/lucaengelhard/lang/src/errorhandling"
+)
+
+const sub = `fn sub(a: int, b: int) -> int { return a - b; }`
+
+func TestNamedArguments(t *testing.T) {
+	expect_value(t, sub+`sub(b: 1, a: 5);`, "4")
+	expect_value(t, sub+`sub(5, b: 1);`, "4")
+}
+
+func TestNamedArgumentErrors(t *testing.T) {
+	expect_error(t, sub+`sub(a: 5, 1);`, errorhandling.TYPE, "Positional arguments can't follow named arguments")
+	expect_error(t, sub+`sub(5, a: 1);`, errorhandling.TYPE, "Argument a is already bound")
+	expect_error(t, sub+`sub(a: 5, c: 1);`, errorhandling.TYPE, "Argument c doesn't exist on sub")
+	expect_error(t, sub+`sub(a: 5);`, errorhandling.TYPE, "Missing argument b")
+}
+
+func TestNamedArgumentsOfFunctionValues(t *testing.T) {
+	apply := `fn apply(f: (a: int, b: int) -> int) -> int { return f(b: 1, a: 5); }`
+	expect_value(t, sub+apply+`apply(sub);`, "4")
+	expect_error(t, apply+`fn other(p: int, q: int) -> int { return p - q; } apply(other);`, errorhandling.TYPE,
+		"Mismatched argument (0). Expected Function<FnArg<a<int>, b<int>>, FnRet<int>>, got Function<FnArg<p<int>, q<int>>, FnRet<int>>")
+}
+
+func TestMutableVariablesArePassedByValue(t *testing.T) {
+	expect_value(t, `fn inc(x: int) -> int { return x + 1; } let mut n = 1; inc(n);`, "2")
+	expect_value(t, `fn f(mut xs: Array<int>) -> int { xs[0] = 100; return xs[0]; } let mut a = [1, 2]; f(a) + a[0];`, "101")
+}
+
+const tens = `fn tens(a: int, b: int) -> int { return a * 10 + b; }`
+
+func TestSpreadRecords(t *testing.T) {
+	expect_value(t, tens+`tens({ b: 1, a: 2 });`, "21")
+	expect_value(t, tens+`let partial = { a: 4 }; tens(partial, b: 5);`, "45")
+	expect_error(t, tens+`tens({ a: 4 });`, errorhandling.TYPE, "Missing argument b")
+}
+
+func TestSpreadArrays(t *testing.T) {
+	expect_value(t, tens+`tens([1, 2]);`, "12")
+	expect_value(t, tens+`let partial = [1]; tens(partial, 3);`, "13")
+	expect_error(t, tens+`tens([1, 2, 3]);`, errorhandling.TYPE, "Array with 3 elements is spread over 2 arguments")
+	expect_error(t, tens+`let xs = [1, 2, 3]; tens(xs);`, errorhandling.RUNTIME, "Array with 3 elements is spread over 2 arguments")
+}
+
+func TestArraysArentSpreadOverArrayParameters(t *testing.T) {
+	expect_value(t, `fn first(xs: Array<int>) -> int { return xs[0]; } first([7, 8]);`, "7")
+}
+
+func TestUncheckedCallsAreCheckedAtRuntime(t *testing.T) {
+	expect_unchecked_error(t, tens+`tens(1, 2, 3);`, "Too many arguments. Expected 2, got 3")
+	expect_unchecked_error(t, tens+`tens(1, c: 2);`, "Argument c doesn't exist")
+	expect_unchecked_error(t, tens+`tens(1, a: 2);`, "Argument a is already bound")
+	expect_unchecked_error(t, tens+`tens(1);`, "Missing argument b")
+}
+
+func TestElementsOfSpreadArraysAreCheckedAtRuntime(t *testing.T) {
+	expect_value(t, tens+`let xs: Array<Union<int, string>> = [1, 2]; tens(xs);`, "12")
+	expect_error(t, tens+`let xs: Array<Union<int, string>> = [1, "a"]; tens(xs);`, errorhandling.RUNTIME, "Element 1 can't be passed as argument b of type int")
+}
+
+func TestUncheckedCallsArentSpread(t *testing.T) {
+	expect_unchecked_error(t, tens+`tens({ a: 1, b: 2 });`, "Missing argument b")
+}
+
+func TestMemberCallsUseTheirBinding(t *testing.T) {
+	expect_value(t, tens+`let x = 2; x.tens(b: 3);`, "23")
+	expect_value(t, tens+`let x = 2; x.tens([4]);`, "24")
+}
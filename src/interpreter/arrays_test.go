@@ -0,0 +1,27 @@
+package interpreter_test
+
+import (
+	"testing"
+
+	"github.com/lucaengelhard/lang/src/errorhandling"
+)
+
+func TestArrayElementsAreUnified(t *testing.T) {
+	expect_value(t, `let a = [1, 2.5]; a[0] / 2;`, "0.5")
+	expect_value(t, `let a: Array<Union<int, string>> = ["a", 1]; a;`, "[a, 1]")
+}
+
+func TestUnionsMatchInAnyOrder(t *testing.T) {
+	expect_value(t, `let c: Union<string, int> = if (true) 1 else "a"; c;`, "1")
+	expect_error(t, `let c: Union<int> = if (true) 1 else "a";`, errorhandling.TYPE, "Type Union<int, string> doesn't match Union<int> (Union<int>)")
+}
+
+func TestIntArraysAreWidenedToFloatArrays(t *testing.T) {
+	expect_value(t, `let a: Array<float> = [1, 3]; a[1] / 2;`, "1.5")
+	expect_error(t, `let a: Array<int> = [1.5];`, errorhandling.TYPE, "Type Array<float> doesn't match Array<int> (Array<int>)")
+}
+
+func TestEmptyArraysTakeTheirTypeFromContext(t *testing.T) {
+	expect_value(t, `let a: Array<int> = []; a;`, "[]")
+	expect_error(t, `let a = [];`, errorhandling.TYPE, "The type of an empty array can't be inferred, add a type annotation")
+}
@@ -0,0 +1,77 @@
+package interpreter
+
+import (
+	"slices"
+
+	"github.com/lucaengelhard/lang/src/ast"
+)
+
+// Members of an enum evaluate to their value, the enum itself is only needed for lookups
+type enum_member struct {
+	Name  string
+	Value any
+}
+
+func interpret_enum_stmt(stmt ast.EnumStmt, env *env) {
+	members := make([]enum_member, 0, len(stmt.Elements))
+
+	for _, element := range stmt.Elements {
+		value, _ := interpret(element.Value, env)
+		members = append(members, enum_member{Name: element.Name, Value: value})
+	}
+
+	root := env.get_root()
+
+	if root.enums == nil {
+		root.enums = map[string][]enum_member{}
+	}
+
+	root.enums[stmt.Identifier] = members
+	env.define_type(stmt.Identifier, ast.CreateBaseType(ast.ENUM))
+}
+
+// Returns the members of the enum an expression refers to. Variables shadow enums of the same name.
+func (env *env) get_enum(expr ast.Expr) (string, []enum_member, bool) {
+	symbol, is_symbol := expr.(ast.SymbolExpr)
+
+	if !is_symbol || symbol.IsReference {
+		return "", nil, false
+	}
+
+	if _, err := env.get(symbol.Value); err == nil {
+		return "", nil, false
+	}
+
+	members, exists := env.get_root().enums[symbol.Value]
+	return symbol.Value, members, exists
+}
+
+func interpret_enum_member(expr ast.ChainExpr, name string, members []enum_member, env *env) any {
+	index := slices.IndexFunc(members, func(member enum_member) bool { return member.Name == expr.Member })
+
+	if index < 0 {
+		env.throw(expr.Position, "Member %s doesn't exist on enum %s", expr.Member, name)
+	}
+
+	return members[index].Value
+}
+
+// Only the type of the value is checked before, a value no member has fails here
+func interpret_enum_lookup(call ast.FnCallExpr, name string, members []enum_member, env *env) any {
+	if len(call.Arguments) != 1 {
+		env.throw(call.Position, "Looking up a member of %s takes exactly one value, got %d", name, len(call.Arguments))
+	}
+
+	value, _ := interpret(call.Arguments[0].Value, env)
+	index := slices.IndexFunc(members, func(member enum_member) bool { return member.Value == value })
+
+	if index < 0 {
+		env.throw(call.Position, "Enum %s has no member with the value %s", name, FormatValue(value))
+	}
+
+	return members[index].Name
+}
+
+func (env *env) is_enum_value(name string, value any) bool {
+	return slices.ContainsFunc(env.get_root().enums[name], func(member enum_member) bool { return member.Value == value })
+}
@@ -0,0 +1,37 @@
+package interpreter_test
+
+import (
+	"testing"
+
+	"github.com/lucaengelhard/lang/src/errorhandling"
+)
+
+const colors = `enum Color { RED, GREEN = 5, BLUE, NAMED = "n", LAST }`
+
+func TestEnumMembers(t *testing.T) {
+	expect_value(t, colors+`Color.RED;`, "0")
+	expect_value(t, colors+`Color.BLUE;`, "6")
+	expect_value(t, colors+`Color.NAMED;`, "n")
+	expect_value(t, colors+`Color.LAST;`, "4")
+	expect_value(t, colors+`let c: Color = Color.GREEN; c;`, "5")
+}
+
+func TestEnumLookups(t *testing.T) {
+	expect_value(t, colors+`Color(6);`, "BLUE")
+	expect_value(t, colors+`Color("n");`, "NAMED")
+	expect_value(t, colors+`Color(Color.GREEN);`, "GREEN")
+}
+
+func TestVariablesShadowEnums(t *testing.T) {
+	expect_value(t, colors+`let Color = 3; Color;`, "3")
+}
+
+func TestEnumErrors(t *testing.T) {
+	expect_error(t, colors+`Color.PINK;`, errorhandling.TYPE, "Member PINK doesn't exist on enum Color")
+	expect_error(t, colors+`Color(true);`, errorhandling.TYPE, "Enum Color has no members of type bool")
+	expect_error(t, colors+`Color(1, 2);`, errorhandling.TYPE, "Looking up a member of Color takes exactly one value, got 2")
+	expect_error(t, colors+`let c: Color = Color.RED; let i: bool = c;`, errorhandling.TYPE,
+		"Type Enum<RED<int>, GREEN<int>, BLUE<int>, NAMED<string>, LAST<int>> doesn't match bool (bool)")
+	expect_error(t, colors+`Color(1);`, errorhandling.RUNTIME, "Enum Color has no member with the value 1")
+	expect_unchecked_error(t, colors+`Color.PINK;`, "Member PINK doesn't exist on enum Color")
+}
@@ -0,0 +1,71 @@
+package interpreter
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/lucaengelhard/lang/src/ast"
+	"github.com/lucaengelhard/lang/src/errorhandling"
+)
+
+// A call of a function that is currently being interpreted
+type frame struct {
+	Name     string
+	Position ast.Position
+}
+
+// RuntimeError is raised (as a panic) by the interpreter and recovered
+// at the entry points, so a failing script doesn't crash the host
+type RuntimeError struct {
+	Message  string
+	Position ast.Position
+	// Active calls at the moment of the error, innermost first
+	Stack []frame
+}
+
+func (err *RuntimeError) Error() string {
+	return err.Message
+}
+
+func (err *RuntimeError) ToError() errorhandling.Error {
+	stack := make([]errorhandling.StackFrame, 0, len(err.Stack))
+
+	for _, f := range err.Stack {
+		stack = append(stack, errorhandling.StackFrame{Name: f.Name, Position: f.Position.Start})
+	}
+
+	return errorhandling.Error{
+		Message:  err.Message,
+		Phase:    errorhandling.RUNTIME,
+		Position: err.Position.Start,
+		End:      err.Position.End,
+		Stack:    stack,
+	}
+}
+
+func (env *env) throw(pos ast.Position, format string, args ...any) {
+	stack := slices.Clone(env.get_root().frames)
+	slices.Reverse(stack)
+
+	panic(&RuntimeError{
+		Message:  fmt.Sprintf(format, args...),
+		Position: pos,
+		Stack:    stack,
+	})
+}
+
+// Converts a panicking RuntimeError into an error; other panics are passed on
+func recover_runtime_error(root *env, recovered any) []errorhandling.Error {
+	if recovered == nil {
+		return nil
+	}
+
+	err, ok := recovered.(*RuntimeError)
+
+	if !ok {
+		panic(recovered)
+	}
+
+	root.frames = root.frames[:0]
+	return []errorhandling.Error{err.ToError()}
+}
@@ -0,0 +1,175 @@
+package interpreter
+
+import (
+	"slices"
+
+	"github.com/lucaengelhard/lang/src/ast"
+)
+
+func interpret_index_expr(expr ast.IndexExpr, env *env) any {
+	container, _ := interpret(expr.Assignee, env)
+	index, _ := interpret(expr.Index, env)
+	return get_element(env, expr.Position, container, index)
+}
+
+// Strings are indexed by characters, not bytes
+func get_element(env *env, pos ast.Position, container any, index any) any {
+	switch container := container.(type) {
+	case []any:
+		return container[array_index(env, pos, index, len(container))]
+	case string:
+		characters := []rune(container)
+		return string(characters[array_index(env, pos, index, len(characters))])
+	case struct_value:
+		key, ok := index.(string)
+		if !ok {
+			env.throw(pos, "Index must be a string, got %s", FormatValue(index))
+		}
+
+		return get_member(env, pos, container, key)
+	}
+
+	env.throw(pos, "Can't index %s", FormatValue(container))
+	return nil
+}
+
+func array_index(env *env, pos ast.Position, index any, length int) int {
+	i, ok := index.(int64)
+
+	if !ok {
+		env.throw(pos, "Index must be an int, got %s", FormatValue(index))
+	}
+
+	if i < 0 || i >= int64(length) {
+		env.throw(pos, "Index %d out of bounds for length %d", i, length)
+	}
+
+	return int(i)
+}
+
+func interpret_slice_expr(expr ast.SliceExpr, env *env) any {
+	container, _ := interpret(expr.Assignee, env)
+
+	switch container := container.(type) {
+	case []any:
+		start, end := slice_bounds(expr, len(container), env)
+		return slices.Clone(container[start:end])
+	case string:
+		characters := []rune(container)
+		start, end := slice_bounds(expr, len(characters), env)
+		return string(characters[start:end])
+	}
+
+	env.throw(expr.Position, "Can't slice %s", FormatValue(container))
+	return nil
+}
+
+// Left out bounds default to the start and end of the container
+func slice_bounds(expr ast.SliceExpr, length int, env *env) (int, int) {
+	bounds := []int64{0, int64(length)}
+
+	for i, bound := range []ast.Expr{expr.Start, expr.End} {
+		if bound == nil {
+			continue
+		}
+
+		value, _ := interpret(bound, env)
+		index, ok := value.(int64)
+
+		if !ok {
+			env.throw(bound.Pos(), "Slice bounds must be ints, got %s", FormatValue(value))
+		}
+
+		bounds[i] = index
+	}
+
+	if bounds[0] < 0 || bounds[0] > bounds[1] || bounds[1] > int64(length) {
+		env.throw(expr.Position, "Slice bounds [%d:%d] out of range for length %d", bounds[0], bounds[1], length)
+	}
+
+	return int(bounds[0]), int(bounds[1])
+}
+
+// A member or index access on the way to an assigned element, with the index already evaluated
+type access struct {
+	is_member bool
+	key       any
+	position  ast.Position
+}
+
+// Evaluates the indices of an access path once, so they aren't evaluated again when the element is written
+func resolve_path(path []ast.Expr, env *env) []access {
+	accesses := make([]access, 0, len(path))
+
+	for _, step := range path {
+		switch step := step.(type) {
+		case ast.ChainExpr:
+			accesses = append(accesses, access{is_member: true, key: step.Member, position: step.Position})
+		case ast.IndexExpr:
+			index, _ := interpret(step.Index, env)
+			accesses = append(accesses, access{key: index, position: step.Position})
+		}
+	}
+
+	return accesses
+}
+
+func (a access) get(env *env, container any) any {
+	if a.is_member {
+		return get_member(env, a.position, container, a.key.(string))
+	}
+
+	return get_element(env, a.position, container, a.key)
+}
+
+// Structs and arrays are copied whenever they are assigned or passed by
+// value, so every variable owns its value and elements can be written in place
+func copy_value(value any) any {
+	switch value := value.(type) {
+	case []any:
+		elements := make([]any, len(value))
+		for index, element := range value {
+			elements[index] = copy_value(element)
+		}
+		return elements
+	case struct_value:
+		fields := make(map[string]any, len(value.Fields))
+		for name, field := range value.Fields {
+			fields[name] = copy_value(field)
+		}
+		return struct_value{Name: value.Name, Fields: fields}
+	}
+
+	return value
+}
+
+func with_element(env *env, container any, path []access, element any) any {
+	step := path[0]
+
+	if len(path) > 1 {
+		element = with_element(env, step.get(env, container), path[1:], element)
+	}
+
+	switch container := container.(type) {
+	case struct_value:
+		key, ok := step.key.(string)
+		if !ok {
+			env.throw(step.position, "Index must be a string, got %s", FormatValue(step.key))
+		}
+
+		if _, exists := container.Fields[key]; !exists {
+			env.throw(step.position, "Property %s doesn't exist on %s", key, container.type_name())
+		}
+
+		container.Fields[key] = element
+		return container
+	case []any:
+		container[array_index(env, step.position, step.key, len(container))] = element
+		return container
+	case string:
+		env.throw(step.position, "Strings can't be changed, characters can't be assigned")
+	}
+
+	env.throw(step.position, "Can't assign an element of %s", FormatValue(container))
+	return nil
+}
@@ -0,0 +1,26 @@
+package interpreter_test
+
+import (
+	"testing"
+
+	"github.com/lucaengelhard/lang/src/errorhandling"
+)
+
+func TestIndexWithMutableVariable(t *testing.T) {
+	expect_value(t, `let a = [1, 2, 3]; let mut sum = 0; for (let mut i = 0; i < 3; i++) { sum += a[i]; } sum;`, "6")
+	expect_value(t, `let r = { x: 1 }; let mut k = "x"; r[k];`, "1")
+}
+
+func TestElementAssignment(t *testing.T) {
+	expect_value(t, `let mut a = [0, 0, 0]; for (let mut i = 0; i < 3; i++) { a[i] = i; } a;`, "[0, 1, 2]")
+	expect_value(t, `let mut grid = [[0, 0], [0, 0]]; grid[1][0] += 5; grid;`, "[[0, 0], [5, 0]]")
+}
+
+func TestAssignedArraysAreCopied(t *testing.T) {
+	expect_value(t, `let mut a = [1, 2]; let mut b = a; b[0] = 9; a;`, "[1, 2]")
+	expect_value(t, `let row = [0, 0]; let mut grid = [row, row]; grid[0][1] = 5; grid;`, "[[0, 5], [0, 0]]")
+}
+
+func TestIndexOutOfRange(t *testing.T) {
+	expect_error(t, `let mut a = [1, 2]; a[2] = 3;`, errorhandling.RUNTIME, "Index 2 out of bounds for length 2")
+}
@@ -2,9 +2,15 @@ package interpreter
 
 import (
 	"fmt"
+	"io"
+	"maps"
 	"reflect"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/lucaengelhard/lang/src/ast"
+	"github.com/lucaengelhard/lang/src/errorhandling"
 	"github.com/lucaengelhard/lang/src/lexer"
 	"github.com/sanity-io/litter"
 )
@@ -19,6 +25,14 @@ type env_decl struct {
 type env struct {
 	Declarations map[string]*env_decl
 	Parent       *env
+	// Call stack, only used on the root env
+	frames []frame
+	// Declared structs, interfaces and enums, only used on the root env
+	types map[string]ast.Type
+	// Members of declared enums, only used on the root env
+	enums map[string][]enum_member
+	// Where print and println write to, only used on the root env
+	out io.Writer
 }
 
 func (env *env) get(identifier string) (*env_decl, error) {
@@ -29,16 +43,16 @@ func (env *env) get(identifier string) (*env_decl, error) {
 	}
 
 	if !exist && env.Parent == nil {
-		return &env_decl{}, fmt.Errorf("Variable %s doesn't exist\n", identifier)
+		return &env_decl{}, fmt.Errorf("Variable %s doesn't exist", identifier)
 	}
 
 	return env.Parent.get(identifier)
 }
 
-func (env *env) set(identifer string, value any, isNew bool, isMutable bool) {
+func (env *env) set(identifer string, value any, isNew bool, isMutable bool) error {
 	if isNew {
 		if _, exists := env.Declarations[identifer]; exists {
-			panic(fmt.Sprintf("%s already exists in scope\n", identifer))
+			return fmt.Errorf("%s already exists in scope", identifer)
 		}
 
 		env.Declarations[identifer] = &env_decl{
@@ -47,19 +61,28 @@ func (env *env) set(identifer string, value any, isNew bool, isMutable bool) {
 			IsReference: false,
 			Value:       value,
 		}
-		return
+		return nil
 	}
 
 	decl, err := env.get(identifer)
 
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	if !decl.IsMutable {
-		panic(fmt.Sprintf("%s is not mutable\n", identifer))
+		return fmt.Errorf("%s is not mutable", identifer)
 	}
 	decl.Value = value
+	return nil
+}
+
+func (env *env) get_root() *env {
+	if env.Parent == nil {
+		return env
+	}
+
+	return env.Parent.get_root()
 }
 
 func (env *env) set_ref(identifer string, ref *env_decl) {
@@ -73,10 +96,48 @@ func createEnv(parent *env) *env {
 	}
 }
 
-func Init(node any) {
-	createOpLookup()
-	//TODO: Error handling with breaking and not breaking
-	interpret(node, createStdEnv())
+var op_lookup_once sync.Once
+
+func Init(node any) []errorhandling.Error {
+	_, errors := CreateEnv().Eval(node)
+	return errors
+}
+
+// Env is a root scope whose declarations persist between several evaluations
+type Env struct {
+	root *env
+}
+
+func CreateEnv() *Env {
+	op_lookup_once.Do(createOpLookup)
+	return &Env{root: createStdEnv()}
+}
+
+// SetOutput makes print and println write to out instead of stdout
+func (e *Env) SetOutput(out io.Writer) {
+	e.root.out = out
+}
+
+// Eval interprets a node directly inside of the root scope and returns the
+// value the node evaluated to (nil for statements without a value).
+func (e *Env) Eval(node any) (value any, errors []errorhandling.Error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			errors = recover_runtime_error(e.root, recovered)
+		}
+	}()
+
+	result, return_value := interpret(node, e.root)
+
+	if signal, ok := return_value.(loop_signal); ok {
+		signal.escaped(e.root)
+	}
+
+	if return_value != nil {
+		return return_value, nil
+	}
+
+	return result, nil
 }
 
 func interpret(node any, env *env) (any, any) {
@@ -103,6 +164,10 @@ func interpret(node any, env *env) (any, any) {
 		result = node.Value
 	case ast.StringExpr:
 		result = node.Value
+	case ast.BoolExpr:
+		result = node.Value
+	case ast.StringTemplateExpr:
+		result = interpret_string_template(node, env)
 	case ast.ArrayInstantiationExpr:
 		result = interpret_arr_instantiation(node, env)
 	case ast.BinaryExpr:
@@ -113,12 +178,40 @@ func interpret(node any, env *env) (any, any) {
 		result = interpret_prefix_expr(node, env)
 	case ast.IfStmt:
 		return_value = interpret_if_stmt(node, env)
+	case ast.IfExpr:
+		result = interpret_if_expr(node, env)
+	case ast.SwitchStmt:
+		return_value = interpret_switch_stmt(node, env)
+	case ast.SwitchExpr:
+		result = interpret_switch_expr(node, env)
+	case ast.StructStmt:
+		interpret_struct_stmt(node, env)
+	case ast.InterfaceStmt:
+		interpret_interface_stmt(node, env)
+	case ast.EnumStmt:
+		interpret_enum_stmt(node, env)
+	case ast.StructInstantiationExpr:
+		result = interpret_struct_instantiation(node, env)
+	case ast.RecordInstantiationExpr:
+		result = interpret_record_instantiation(node, env)
+	case ast.ChainExpr:
+		result = interpret_chain_expr(node, env)
+	case ast.IndexExpr:
+		result = interpret_index_expr(node, env)
+	case ast.SliceExpr:
+		result = interpret_slice_expr(node, env)
 	case ast.ForStmt:
 		return_value = interpret_for_stmt(node, env)
+	case ast.ForInStmt:
+		return_value = interpret_for_in_stmt(node, env)
 	case ast.WhileStmt:
 		return_value = interpret_while_stmt(node, env)
 	case ast.ReturnStmt:
 		return_value, _ = interpret(node.Value, env)
+	case ast.BreakStmt:
+		return_value = loop_signal{is_break: true, label: node.Label, position: node.Position}
+	case ast.ContinueStmt:
+		return_value = loop_signal{label: node.Label, position: node.Position}
 	default:
 		fmt.Printf("Unhandled: %s\n", reflect.TypeOf(node))
 		litter.Dump(node)
@@ -127,6 +220,19 @@ func interpret(node any, env *env) (any, any) {
 	return result, return_value
 }
 
+func interpret_string_template(template ast.StringTemplateExpr, env *env) string {
+	var builder strings.Builder
+	builder.WriteString(template.Parts[0])
+
+	for i, expr := range template.Expressions {
+		value, _ := interpret(expr, env)
+		builder.WriteString(FormatValue(value))
+		builder.WriteString(template.Parts[i+1])
+	}
+
+	return builder.String()
+}
+
 func interpret_block(input any, env *env) any {
 	block, _ := input.(ast.BlockStmt)
 	scope := createEnv(env)
@@ -143,13 +249,20 @@ func interpret_block(input any, env *env) any {
 func interpret_declaration(input any, env *env) {
 	declaration, _ := input.(ast.DeclarationStmt)
 	val, _ := interpret(declaration.AssignedValue, env)
-	env.set(declaration.Identifier, val, true, declaration.IsMutable)
+
+	if err := env.set(declaration.Identifier, widen(copy_value(val), declaration.Type), true, declaration.IsMutable); err != nil {
+		env.throw(declaration.Position, "%s", err.Error())
+	}
 }
 
 type FnCallArg struct {
 	Identifier string
 	Value      any
 	Reference  *env_decl
+	// Receivers of member calls carry their value and their reference, the
+	// parameter decides which one is bound
+	Receiver bool
+	Position ast.Position
 }
 
 func interpret_fn_declaration(input any, env *env) func(args ...FnCallArg) any {
@@ -163,49 +276,40 @@ func interpret_fn_declaration(input any, env *env) func(args ...FnCallArg) any {
 
 	return func(args ...FnCallArg) any {
 		scope := createEnv(env)
-		var NAMED_ARG_FLAG = false
-		for index, passed_arg := range args {
-			var definition_arg ast.FnArg
-
-			if passed_arg.Identifier != "" {
-				named_arg, exists := declaration.Arguments[passed_arg.Identifier]
-
-				if !exists {
-					panic(fmt.Sprintf("Argument %s doesn't exist on function", passed_arg.Identifier))
-				}
-
-				definition_arg = named_arg
-				NAMED_ARG_FLAG = true
-			} else {
-				if NAMED_ARG_FLAG {
-					panic("Positional arguments not allowed after named arguments have been set")
-				}
-				definition_arg = position_arg_map[index]
-			}
+		for _, passed_arg := range scope.bind_positional(position_arg_map, args) {
+			definition_arg := scope.check_binding(declaration.Arguments, passed_arg)
 
 			if definition_arg.Type.Name == ast.REFERENCE {
 				if passed_arg.Reference == nil {
-					panic(fmt.Sprintf("Expected reference for argument %s (%v)\n", definition_arg.Identifier, definition_arg.ArgIndex))
+					scope.throw(passed_arg.Position, "Expected reference for argument %s (%v)", definition_arg.Identifier, definition_arg.ArgIndex)
 				}
 
 				if definition_arg.IsMutable && !passed_arg.Reference.IsMutable {
-					panic(fmt.Sprintf("Expected mutable reference for argument %s (%v)\n", definition_arg.Identifier, definition_arg.ArgIndex))
+					scope.throw(passed_arg.Position, "Expected mutable reference for argument %s (%v)", definition_arg.Identifier, definition_arg.ArgIndex)
 				}
 
 				scope.set_ref(definition_arg.Identifier, passed_arg.Reference)
 			}
 
 			if definition_arg.Type.Name != ast.REFERENCE {
-				if passed_arg.Reference != nil {
-					panic(fmt.Sprintf("Expected argument %s (%v) to be passed by value, got reference\n", definition_arg.Identifier, definition_arg.ArgIndex))
+				if passed_arg.Reference != nil && !passed_arg.Receiver {
+					scope.throw(passed_arg.Position, "Expected argument %s (%v) to be passed by value, got reference", definition_arg.Identifier, definition_arg.ArgIndex)
 				}
 
-				scope.set(definition_arg.Identifier, passed_arg.Value, true, definition_arg.IsMutable)
+				if err := scope.set(definition_arg.Identifier, copy_value(passed_arg.Value), true, definition_arg.IsMutable); err != nil {
+					scope.throw(passed_arg.Position, "%s", err.Error())
+				}
 			}
 		}
 
+		scope.check_missing(position_arg_map)
+
 		for _, stmt := range block.Body {
 			_, ret := interpret(stmt, scope)
+			if signal, ok := ret.(loop_signal); ok {
+				signal.escaped(scope)
+			}
+
 			if ret != nil {
 				return ret
 			}
@@ -216,13 +320,27 @@ func interpret_fn_declaration(input any, env *env) func(args ...FnCallArg) any {
 
 func interpret_fn_call(input any, env *env) any {
 	call, _ := input.(ast.FnCallExpr)
-	caller_symbol := call.Caller.(ast.SymbolExpr)
+	name := ast.ExprName(call.Caller)
 
-	declaration, _ := env.get(caller_symbol.Value)
-	fn, _ := declaration.Value.(func(args ...FnCallArg) any)
+	if enum, members, is_enum := env.get_enum(call.Caller); is_enum {
+		return interpret_enum_lookup(call, enum, members, env)
+	}
 
+	var caller any
 	args := make([]FnCallArg, 0)
 
+	if chain, is_member := call.Caller.(ast.ChainExpr); is_member {
+		caller, args = env.member_caller(chain)
+	} else {
+		caller, _ = interpret(call.Caller, env)
+	}
+
+	fn, ok := caller.(func(args ...FnCallArg) any)
+
+	if !ok {
+		env.throw(call.Position, "%s is not a function", name)
+	}
+
 	for _, arg := range call.Arguments {
 		val, _ := interpret(arg.Value, env)
 		var reference *env_decl
@@ -241,31 +359,60 @@ func interpret_fn_call(input any, env *env) any {
 			Identifier: identifier,
 			Value:      val,
 			Reference:  reference,
+			Position:   arg.Position,
 		})
 	}
 
-	return fn(args...)
+	// Receivers the typechecker didn't see leave the call unresolved
+	receiver := len(args) > len(call.Arguments)
+	if call.Binding != nil && call.Binding.Resolved && call.Binding.Receiver == receiver {
+		args = env.apply_binding(call.Binding, args)
+	}
+
+	root := env.get_root()
+	root.frames = append(root.frames, frame{Name: name, Position: call.Position})
+	result := fn(args...)
+	root.frames = root.frames[:len(root.frames)-1]
+
+	return result
 }
 
 func interpret_assignment(input any, env *env) {
 	assignment, _ := input.(ast.AssignmentExpr)
-	assignee, _ := assignment.Assignee.(ast.SymbolExpr)
+	assignee, path, ok := ast.AccessPath(assignment.Assignee)
 	right_result, _ := interpret(assignment.Right, env)
+	right_result = copy_value(right_result)
+
+	if !ok {
+		env.throw(assignment.Position, "Can't assign to this expression")
+	}
+
+	accesses := resolve_path(path, env)
 
-	current, error := env.get(assignee.Value)
+	current, err := env.get(assignee.Value)
 
-	if error != nil {
-		panic(error)
+	if err != nil {
+		env.throw(assignment.Position, "%s", err.Error())
 	}
 
 	op_token, op_token_exists := lexer.Assignment_operation_lu[assignment.Operator.Kind]
 
 	if op_token_exists {
-		env.set(assignee.Value, execute_binop(op_token, current.Value, right_result), false, false)
-	} else {
-		env.set(assignee.Value, right_result, false, false)
+		current_value := current.Value
+		for _, step := range accesses {
+			current_value = step.get(env, current_value)
+		}
+
+		right_result = execute_binop(env, assignment.Position, op_token, current_value, right_result)
 	}
 
+	if len(accesses) > 0 {
+		right_result = with_element(env, current.Value, accesses, right_result)
+	}
+
+	if err := env.set(assignee.Value, right_result, false, false); err != nil {
+		env.throw(assignment.Position, "%s", err.Error())
+	}
 }
 
 func interpret_symbol_expr(input any, env *env) any {
@@ -273,7 +420,7 @@ func interpret_symbol_expr(input any, env *env) any {
 	value, err := env.get(symbol.Value)
 
 	if err != nil {
-		panic(err)
+		env.throw(symbol.Position, "%s", err.Error())
 	}
 
 	return value.Value
@@ -284,7 +431,7 @@ func interpret_binary_exp(input any, env *env) any {
 	left_result, _ := interpret(expression.Left, env)
 	right_result, _ := interpret(expression.Right, env)
 
-	return execute_binop(expression.Operator.Kind, left_result, right_result)
+	return execute_binop(env, expression.Position, expression.Operator.Kind, left_result, right_result)
 }
 
 func interpret_prefix_expr(input any, env *env) any {
@@ -293,7 +440,7 @@ func interpret_prefix_expr(input any, env *env) any {
 
 	switch expression.Operator.Kind {
 	case lexer.MINUS:
-		return execute_binop(lexer.STAR, int64(-1), right_result)
+		return execute_binop(env, expression.Position, lexer.STAR, int64(-1), right_result)
 
 	default:
 		fmt.Printf("Unhandled prefix: %s\n", expression.Operator.Kind.ToString())
@@ -317,66 +464,144 @@ func interpret_if_stmt(input any, env *env) any {
 	return return_value
 }
 
+func interpret_if_expr(expr ast.IfExpr, env *env) any {
+	cond, _ := interpret(expr.Condition, env)
+	decision, _ := cond.(bool)
+
+	var result any
+	if decision {
+		result, _ = interpret(expr.True, env)
+	} else {
+		result, _ = interpret(expr.False, env)
+	}
+
+	return result
+}
+
 func interpret_for_stmt(input any, env *env) any {
 	stmt, _ := input.(ast.ForStmt)
 	scope := createEnv(env)
 
 	interpret(stmt.Assignment, scope)
 
-	var ret any
-	for true {
+	for {
 		result, _ := interpret(stmt.Condition, scope)
 		condition, _ := result.(bool)
 
 		if !condition {
-			break
+			return nil
 		}
 
-		_, ret = interpret(stmt.Body, scope)
+		_, ret := interpret(stmt.Body, scope)
 
-		if ret != nil {
-			break
+		if stop, value := loop_control(ret, stmt.Label); stop {
+			return value
 		}
 
 		interpret(stmt.Increment, scope)
+	}
+}
+
+func interpret_for_in_stmt(stmt ast.ForInStmt, env *env) any {
+	iterable, _ := interpret(stmt.Iterable, env)
 
+	// Every iteration gets its own scope, so closures capture the element of their iteration
+	iteration := func(element any, index any) any {
+		scope := createEnv(env)
+		scope.set(stmt.Element, element, true, false)
+
+		if stmt.Index != "" {
+			scope.set(stmt.Index, index, true, false)
+		}
+
+		_, ret := interpret(stmt.Body, scope)
+		return ret
+	}
+
+	switch iterable := iterable.(type) {
+	case []any:
+		for index, element := range iterable {
+			if stop, value := loop_control(iteration(element, int64(index)), stmt.Label); stop {
+				return value
+			}
+		}
+	case struct_value:
+		for _, key := range slices.Sorted(maps.Keys(iterable.Fields)) {
+			if stop, value := loop_control(iteration(iterable.Fields[key], key), stmt.Label); stop {
+				return value
+			}
+		}
+	default:
+		env.throw(stmt.Iterable.Pos(), "Can't iterate over %s", FormatValue(iterable))
 	}
 
-	return ret
+	return nil
 }
 
 func interpret_while_stmt(input any, env *env) any {
 	stmt, _ := input.(ast.WhileStmt)
 	scope := createEnv(env)
 
-	var ret any
-	for true {
+	for {
 		result, _ := interpret(stmt.Condition, scope)
 		condition, _ := result.(bool)
 
 		if !condition {
-			break
+			return nil
 		}
 
-		_, ret = interpret(stmt.Body, scope)
+		_, ret := interpret(stmt.Body, scope)
 
-		if ret != nil {
-			break
+		if stop, value := loop_control(ret, stmt.Label); stop {
+			return value
 		}
+	}
+}
+
+// Arrays of ints declared as arrays of floats get their elements widened
+func widen(value any, t ast.Type) any {
+	elements, is_array := value.([]any)
+	t = t.Strip(ast.MUTABLE)
 
+	if !is_array || !t.Is(ast.ARRAY) || len(t.Arguments) != 1 || !t.Arguments[0].Is(ast.FLOAT) {
+		return value
+	}
+
+	for i, el := range elements {
+		if integer, ok := el.(int64); ok {
+			elements[i] = float64(integer)
+		}
 	}
 
-	return ret
+	return elements
 }
 
 func interpret_arr_instantiation(input any, env *env) any {
 	arr, _ := input.(ast.ArrayInstantiationExpr)
 
-	res := make([]any, 0)
+	res := make([]any, 0, len(arr.Elements))
+	has_floats, only_numbers := false, true
 
 	for _, el := range arr.Elements {
 		el_res, _ := interpret(el, env)
 		res = append(res, el_res)
+
+		switch el_res.(type) {
+		case float64:
+			has_floats = true
+		case int64:
+		default:
+			only_numbers = false
+		}
+	}
+
+	// Arrays of ints and floats are typed as arrays of floats
+	if has_floats && only_numbers {
+		for i, el := range res {
+			if integer, ok := el.(int64); ok {
+				res[i] = float64(integer)
+			}
+		}
 	}
 
 	return res
@@ -0,0 +1,93 @@
+package interpreter_test
+
+import (
+	"testing"
+
+	"github.com/lucaengelhard/lang/src/ast"
+	"github.com/lucaengelhard/lang/src/errorhandling"
+	"github.com/lucaengelhard/lang/src/interpreter"
+	"github.com/lucaengelhard/lang/src/lexer"
+	"github.com/lucaengelhard/lang/src/parser"
+	"github.com/lucaengelhard/lang/src/typechecker"
+)
+
+// Typechecks (unless skipped) and interprets a source. Returns the value of
+// the last statement if it's an expression and the errors of the first phase
+// that failed.
+func execute(t *testing.T, source string, skip_check bool) (string, []errorhandling.Error) {
+	t.Helper()
+	tokens, errors := lexer.Tokenize(source)
+
+	if len(errors) > 0 {
+		return "", errors
+	}
+
+	tree, errors := parser.Parse(tokens)
+
+	if len(errors) > 0 {
+		return "", errors
+	}
+
+	block := tree.(ast.BlockStmt)
+
+	if !skip_check {
+		if _, errors := typechecker.New().Check(block); len(errors) > 0 {
+			return "", errors
+		}
+	}
+
+	env := interpreter.CreateEnv()
+	var value any
+
+	for _, stmt := range block.Body {
+		if value, errors = env.Eval(stmt); len(errors) > 0 {
+			return "", errors
+		}
+	}
+
+	if _, is_expression := block.Body[len(block.Body)-1].(ast.ExpressionStmt); !is_expression {
+		return "", nil
+	}
+
+	return interpreter.FormatValue(value), nil
+}
+
+// Expects the source to run without errors and to end with an expression of the given value
+func expect_value(t *testing.T, source string, expected string) {
+	t.Helper()
+	value, errors := execute(t, source, false)
+
+	if len(errors) > 0 {
+		t.Fatalf("unexpected error in %q: %s", source, errors[0].Message)
+	}
+
+	if value != expected {
+		t.Errorf("expected %q to evaluate to %s, got %s", source, expected, value)
+	}
+}
+
+// Expects the source to fail with an error of the given phase and message
+func expect_error(t *testing.T, source string, phase errorhandling.Phase, message string) {
+	t.Helper()
+	_, errors := execute(t, source, false)
+	expect_message(t, source, errors, phase, message)
+}
+
+// Expects the source to fail at runtime with the given message when it isn't typechecked
+func expect_unchecked_error(t *testing.T, source string, message string) {
+	t.Helper()
+	_, errors := execute(t, source, true)
+	expect_message(t, source, errors, errorhandling.RUNTIME, message)
+}
+
+func expect_message(t *testing.T, source string, errors []errorhandling.Error, phase errorhandling.Phase, message string) {
+	t.Helper()
+
+	for _, err := range errors {
+		if err.Phase == phase && err.Message == message {
+			return
+		}
+	}
+
+	t.Errorf("expected %s error %q in %q, got %v", phase, message, source, errors)
+}
@@ -0,0 +1,46 @@
+package interpreter
+
+import "github.com/lucaengelhard/lang/src/ast"
+
+// break and continue are passed up like return values until the loop
+// they target handles them
+type loop_signal struct {
+	is_break bool
+	// Empty if the innermost loop is targeted
+	label    string
+	position ast.Position
+}
+
+// Handles what a loop body returned. Returns whether the loop has to stop
+// and the value the loop itself returns.
+func loop_control(ret any, label string) (bool, any) {
+	if ret == nil {
+		return false, nil
+	}
+
+	signal, ok := ret.(loop_signal)
+
+	if !ok || signal.label != "" && signal.label != label {
+		return true, ret
+	}
+
+	if signal.is_break {
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// The typechecker prevents signals from leaving loops, this only happens when it was skipped
+func (signal loop_signal) escaped(env *env) {
+	keyword := "continue"
+	if signal.is_break {
+		keyword = "break"
+	}
+
+	if signal.label != "" {
+		env.throw(signal.position, "No loop labeled %s around %s", signal.label, keyword)
+	}
+
+	env.throw(signal.position, "%s outside of a loop", keyword)
+}
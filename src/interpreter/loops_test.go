@@ -0,0 +1,53 @@
+package interpreter_test
+
+import (
+	"testing"
+
+	"github.com/lucaengelhard/lang/src/errorhandling"
+)
+
+func TestForInArray(t *testing.T) {
+	expect_value(t, `let mut sum = 0; for (x, i in [1, 2, 3]) { sum += x * i; } sum;`, "8")
+}
+
+func TestForInRecord(t *testing.T) {
+	expect_value(t, `let mut keys = ""; for (v, k in { b: 1, a: 2 }) { keys = keys + k; } keys;`, "ab")
+}
+
+func TestReturnFromLoop(t *testing.T) {
+	expect_value(t, `fn first(xs: Array<int>) -> int { for (x in xs) { if (x > 1) { return x; } } return 0; } first([1, 5, 7]);`, "5")
+}
+
+func TestLoopsDontReturnAValue(t *testing.T) {
+	expect_error(t, `fn f(c: bool) -> int { while (c) { return 1; } }`, errorhandling.TYPE, "Not every path returns a value, expected int")
+	expect_error(t, `fn f(xs: Array<int>) -> int { for (x in xs) { return x; } }`, errorhandling.TYPE, "Not every path returns a value, expected int")
+}
+
+func TestBreak(t *testing.T) {
+	expect_value(t, `let mut i = 0; while (true) { i++; if (i == 3) { break; } } i;`, "3")
+	expect_value(t, `let mut sum = 0; for (x in [1, 2, 3, 4]) { if (x == 3) { break; } sum += x; } sum;`, "3")
+}
+
+func TestContinue(t *testing.T) {
+	expect_value(t, `let mut sum = 0; for (let mut i = 0; i < 5; i++) { if (i == 2) { continue; } sum += i; } sum;`, "8")
+}
+
+func TestLabeledLoops(t *testing.T) {
+	source := `
+let mut pairs = 0;
+outer: for (x in [1, 2, 3]) {
+  for (y in [1, 2, 3]) {
+    if (y > x) { continue outer; }
+    if (x == 3) { break outer; }
+    pairs++;
+  }
+}
+pairs;`
+	expect_value(t, source, "3")
+}
+
+func TestBreakOutsideOfLoopsAtRuntime(t *testing.T) {
+	expect_unchecked_error(t, `break;`, "break outside of a loop")
+	expect_unchecked_error(t, `while (true) { continue outer; }`, "No loop labeled outer around continue")
+	expect_unchecked_error(t, `fn f() { break; } while (true) { f(); }`, "break outside of a loop")
+}
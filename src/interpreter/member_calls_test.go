@@ -0,0 +1,37 @@
+package interpreter_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lucaengelhard/lang/src/errorhandling"
+)
+
+const double = `fn double(x: int) -> int { return x * 2; }`
+
+func TestMemberCalls(t *testing.T) {
+	expect_value(t, double+`let a = 3; a.double();`, "6")
+	expect_value(t, double+`let a = 3; a.double().double();`, "12")
+}
+
+func TestPropertiesAreCalledBeforeFunctions(t *testing.T) {
+	expect_value(t, double+`let r = { double: fn (x: int) -> int { return x + 1; } }; r.double(1);`, "2")
+}
+
+func TestReceiversArePassedByReference(t *testing.T) {
+	expect_value(t, `struct P { x: int; } fn set(mut p: *P) { p.x = 5; } let mut q = P { x: 1 }; q.set(); q.x;`, "5")
+	expect_value(t, `fn set_first(mut xs: *Array<int>, x: int) { xs[0] = x; } let mut xs = [1, 2]; xs.set_first(3); xs;`, "[3, 2]")
+}
+
+func TestReceiversAreSpread(t *testing.T) {
+	expect_value(t, `fn foo(a: int, b: string) -> string { return b + "!"; } let r = { a: 1, b: "x" }; r.foo();`, "x!")
+	expect_value(t, `fn tens(a: int, b: int) -> int { return a * 10 + b; } let xs = [1, 2]; xs.tens();`, "12")
+}
+
+func TestUnknownMember(t *testing.T) {
+	expect_error(t, `let a = 3; a.nothing();`, errorhandling.TYPE, "Can't access property nothing on int")
+}
+
+func TestLongMemberChains(t *testing.T) {
+	expect_value(t, double+`let a = 1; a`+strings.Repeat(".double()", 40)+` > 0;`, "true")
+}
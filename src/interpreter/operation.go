@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"reflect"
 
+	"github.com/lucaengelhard/lang/src/ast"
 	"github.com/lucaengelhard/lang/src/lexer"
 	"github.com/lucaengelhard/lang/src/lib"
 )
@@ -51,31 +52,41 @@ func create_binop_with_cast[From any, To any, Return any](token lexer.TokenKind,
 	create_binop(token, right)
 }
 
-func get_op(token lexer.TokenKind, left any, right any) binop {
-	err_str := fmt.Sprintf("No operation for %s and %s\n", reflect.TypeOf(left), reflect.TypeOf(right))
+func get_op(token lexer.TokenKind, left any, right any) (binop, error) {
+	err := fmt.Errorf("No operation %s for %s and %s", token.ToString(), value_type_name(left), value_type_name(right))
 	tk, exists_tk := binop_lu[token]
 
 	if !exists_tk {
-		panic(err_str)
+		return nil, err
 	}
 
 	l, exist_l := tk[reflect.TypeOf(left)]
 
 	if !exist_l {
-		panic(err_str)
+		return nil, err
 	}
 
 	op, exists_r := l[reflect.TypeOf(right)]
 
 	if !exists_r {
-		panic(err_str)
+		return nil, err
 	}
 
-	return op
+	return op, nil
 }
 
-func execute_binop(token lexer.TokenKind, left any, right any) any {
-	return get_op(token, left, right)(left, right)
+func execute_binop(env *env, pos ast.Position, token lexer.TokenKind, left any, right any) any {
+	op, err := get_op(token, left, right)
+
+	if err != nil {
+		env.throw(pos, "%s", err.Error())
+	}
+
+	if divisor, ok := right.(int64); ok && divisor == 0 && (token == lexer.SLASH || token == lexer.PERCENT) {
+		env.throw(pos, "Integer division by zero")
+	}
+
+	return op(left, right)
 }
 
 func createOpLookup() {
@@ -93,6 +104,12 @@ func createOpLookup() {
 
 	create_binop(lexer.EQUALS, eq[int64])
 	create_binop(lexer.EQUALS, eq[float64])
+	create_binop(lexer.EQUALS, eq[string])
+	create_binop(lexer.EQUALS, eq[bool])
+	create_binop(lexer.NOT_EQUALS, not_eq[int64])
+	create_binop(lexer.NOT_EQUALS, not_eq[float64])
+	create_binop(lexer.NOT_EQUALS, not_eq[string])
+	create_binop(lexer.NOT_EQUALS, not_eq[bool])
 	create_binop(lexer.GREATER, greater[int64])
 	create_binop_with_cast(lexer.GREATER, greater[float64], int_to_float)
 	create_binop(lexer.LESS, lesser[int64])
@@ -139,6 +156,10 @@ func eq[T lib.Compareable](l T, r T) bool {
 	return l == r
 }
 
+func not_eq[T lib.Compareable](l T, r T) bool {
+	return l != r
+}
+
 func greater[T lib.Orderable](l T, r T) bool {
 	return l > r
 }
@@ -0,0 +1,86 @@
+package interpreter
+
+import (
+	"reflect"
+
+	"github.com/lucaengelhard/lang/src/ast"
+)
+
+// Interprets the body of the first arm that matches the subject. Returns
+// its value, the value it returned and whether an arm matched at all.
+func interpret_switch(subject ast.Expr, arms []ast.SwitchArm, env *env) (any, any, bool) {
+	value, _ := interpret(subject, env)
+
+	for _, arm := range arms {
+		scope := createEnv(env)
+
+		if !scope.match_pattern(arm.Pattern, value) {
+			continue
+		}
+
+		if arm.Guard != nil {
+			guard, _ := interpret(arm.Guard, scope)
+			if passed, _ := guard.(bool); !passed {
+				continue
+			}
+		}
+
+		result, return_value := interpret(arm.Body, scope)
+		return result, return_value, true
+	}
+
+	return nil, nil, false
+}
+
+func interpret_switch_stmt(stmt ast.SwitchStmt, env *env) any {
+	_, return_value, _ := interpret_switch(stmt.Subject, stmt.Arms, env)
+	return return_value
+}
+
+func interpret_switch_expr(expr ast.SwitchExpr, env *env) any {
+	result, _, matched := interpret_switch(expr.Subject, expr.Arms, env)
+
+	if !matched {
+		env.throw(expr.Position, "No arm of the switch matched")
+	}
+
+	return result
+}
+
+// Matches a value against a pattern and declares the variables the pattern captures
+func (env *env) match_pattern(pattern ast.Pattern, value any) bool {
+	switch pattern := pattern.(type) {
+	case ast.ValuePattern:
+		expected, _ := interpret(pattern.Value, env)
+		return reflect.DeepEqual(expected, value)
+	case ast.ConditionPattern:
+		condition, _ := interpret(pattern.Condition, env)
+		passed, _ := condition.(bool)
+		return passed
+	case ast.NamePattern:
+		if env.is_type_name(pattern.Name) {
+			return env.value_is(value, ast.CreateBaseType(pattern.Name))
+		}
+
+		if err := env.set(pattern.Name, value, true, false); err != nil {
+			env.throw(pattern.Position, "%s", err.Error())
+		}
+		return true
+	case ast.DestructurePattern:
+		if !env.value_is(value, ast.CreateBaseType(pattern.Type)) {
+			return false
+		}
+
+		instance := value.(struct_value)
+		for _, field := range pattern.Fields {
+			field_value, exists := instance.Fields[field.Name]
+			if !exists || !env.match_pattern(field.Pattern, field_value) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
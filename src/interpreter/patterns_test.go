@@ -0,0 +1,81 @@
+package interpreter_test
+
+import (
+	"testing"
+
+	"github.com/lucaengelhard/lang/src/errorhandling"
+)
+
+const shapes = `
+struct Circle { r: int; }
+struct Rect { w: int; h: int; }
+interface Sized { w: int; }
+`
+
+func TestValuePatterns(t *testing.T) {
+	expect_value(t, `let x = 2; let r = switch x { 1 => "one", 2 => "two", _ => "other" }; r;`, "two")
+	expect_value(t, `let x = "b"; let r = switch x { "a" => 1, "b" => 2, _ => 3 }; r;`, "2")
+}
+
+func TestFirstMatchingArmIsTaken(t *testing.T) {
+	expect_value(t, `let x = 1; let r = switch x { 1 => "first", 1 => "second", _ => "other" }; r;`, "first")
+}
+
+func TestConditionPatterns(t *testing.T) {
+	expect_value(t, `let x = 5; let r = switch x { x < 3 => "small", x < 10 => "medium", _ => "large" }; r;`, "medium")
+}
+
+func TestCaptureAll(t *testing.T) {
+	expect_value(t, `let x = 7; let r = switch x { 1 => 0, other => other * 2 }; r;`, "14")
+}
+
+func TestGuards(t *testing.T) {
+	expect_value(t, `let x = 4; let r = switch x { y if y > 5 => "big", y if y > 3 => "medium", _ => "small" }; r;`, "medium")
+}
+
+func TestTypePatterns(t *testing.T) {
+	source := shapes + `
+fn area(s: Union<Circle, Rect>) -> int {
+  switch s {
+    Circle => { return 3 * s.r * s.r; },
+    Rect => { return s.w * s.h; },
+    _ => { return 0; },
+  }
+}
+area(Rect { w: 2, h: 3 }) + area(Circle { r: 1 });`
+	expect_value(t, source, "9")
+}
+
+func TestInterfacePatternsMatchByProperties(t *testing.T) {
+	expect_value(t, shapes+`let s = Rect { w: 2, h: 3 }; let r = switch s { Sized => "sized", _ => "other" }; r;`, "sized")
+}
+
+func TestDestructurePatterns(t *testing.T) {
+	expect_value(t, shapes+`let s = Rect { w: 2, h: 3 }; let r = switch s { Rect{w: 1} => 0, Rect{w, h: height} => w * 10 + height, _ => 1 }; r;`, "23")
+}
+
+func TestSwitchStatement(t *testing.T) {
+	source := `
+fn sign(x: int) -> int {
+  switch x {
+    x < 0 => { return -1; },
+    0 => { return 0; },
+    _ => { return 1; },
+  }
+}
+sign(-5) + sign(0) * 10 + sign(3) * 100;`
+	expect_value(t, source, "99")
+}
+
+func TestNoMatchingArm(t *testing.T) {
+	expect_error(t, `let x = 3; let y = switch x { 1 => 1, 2 => 2 };`, errorhandling.RUNTIME, "No arm of the switch matched")
+}
+
+func TestPatternThatCantMatch(t *testing.T) {
+	expect_error(t, `let x = 3; let r = switch x { "a" => 1, _ => 2 }; r;`, errorhandling.TYPE, "Pattern of type string can never match a value of type int")
+}
+
+func TestSwitchStatementWithoutCatchAllDoesntReturn(t *testing.T) {
+	expect_error(t, `fn f(x: int) -> int { switch x { 1 => { return 1; }, 2 => { return 2; } } }`, errorhandling.TYPE, "Not every path returns a value, expected int")
+	expect_error(t, `fn f(x: int) -> int { switch x { 1 => { return 1; }, y => { println(y); } } }`, errorhandling.TYPE, "Not every path returns a value, expected int")
+}
@@ -0,0 +1,43 @@
+package interpreter_test
+
+import (
+	"testing"
+
+	"github.com/lucaengelhard/lang/src/errorhandling"
+)
+
+const named = `interface Named { name: string; }`
+
+func TestRecordLiterals(t *testing.T) {
+	expect_value(t, `{ name: "abc", age: 3 };`, "{age: 3, name: abc}")
+	expect_error(t, `let r = { b: 1, a: "x" }; let i: int = r;`, errorhandling.TYPE, "Type Dict<b<int>, a<string>> doesn't match int (int)")
+	expect_error(t, `{ a: 1, a: 2 };`, errorhandling.PARSER, "Property a already exists on record")
+}
+
+func TestRecordFields(t *testing.T) {
+	expect_value(t, `let r = { name: "abc", age: 3 }; r.name;`, "abc")
+	expect_value(t, `let r = { name: "abc", age: 3 }; r["age"];`, "3")
+	expect_value(t, `let mut r = { a: 1 }; r.a = 2; r.a;`, "2")
+	expect_value(t, `let mut r = { a: 1 }; let s = r; r.a = 2; s.a;`, "1")
+}
+
+func TestRecordFieldErrors(t *testing.T) {
+	expect_error(t, `let r = { a: 1 }; r.b;`, errorhandling.TYPE, "Property b doesn't exist on Dict<a<int>>")
+	expect_error(t, `let r = { a: 1 }; r["b"];`, errorhandling.TYPE, "Property b doesn't exist on Dict<a<int>>")
+	expect_error(t, `let mut r = { a: 1 }; r.a = "s";`, errorhandling.TYPE, "Type string is not assignable to r.a of type int")
+	expect_error(t, `let r = { a: 1 }; r.a = 2;`, errorhandling.TYPE, "Can't assign to r.a, r is not mutable")
+	expect_unchecked_error(t, `let r = { a: 1 }; r.b;`, "Property b doesn't exist on record")
+	expect_unchecked_error(t, `let r = { a: 1 }; r["b"];`, "Property b doesn't exist on record")
+}
+
+func TestRecordsSatisfyInterfaces(t *testing.T) {
+	expect_value(t, named+`let r = { name: "abc", age: 3 }; let n: Named = r; n.name;`, "abc")
+	expect_value(t, named+`fn f(n: Named) -> string { return n.name; } f({ name: "x", other: 1 });`, "x")
+}
+
+func TestRecordInterfaceMismatches(t *testing.T) {
+	expect_error(t, named+`let n: Named = { age: 3 };`, errorhandling.TYPE, "Type Dict<age<int>> doesn't match Named (Dict<name<string>>)")
+	expect_error(t, named+`let n: Named = { name: 3 };`, errorhandling.TYPE, "Type Dict<name<int>> doesn't match Named (Dict<name<string>>)")
+	// Records a parameter can't take are spread into named arguments
+	expect_error(t, named+`fn f(n: Named) -> string { return n.name; } f({ other: 1 });`, errorhandling.TYPE, "Argument other doesn't exist on f")
+}
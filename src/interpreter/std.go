@@ -1,32 +1,96 @@
 package interpreter
 
-import "fmt"
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/lucaengelhard/lang/src/ast"
+)
 
 func createStdEnv() *env {
 	scope := createEnv(nil)
-	scope.set("print", std_print, true, false)
-	scope.set("println", std_println, true, false)
+	scope.out = os.Stdout
+	scope.set("print", scope.std_print, true, false)
+	scope.set("println", scope.std_println, true, false)
 	return scope
 }
 
-func std_print(input ...FnCallArg) any {
-	args := make([]any, 0)
-
-	for _, arg := range input {
-		args = append(args, arg.Value)
-	}
+func (root *env) std_print(input ...FnCallArg) any {
+	fmt.Fprint(root.out, format_args(input))
+	return nil
+}
 
-	fmt.Print(args...)
+func (root *env) std_println(input ...FnCallArg) any {
+	fmt.Fprintln(root.out, format_args(input))
 	return nil
 }
 
-func std_println(input ...FnCallArg) any {
-	args := make([]any, 0)
+// Arguments are formatted like embedded expressions in strings, separated by spaces
+func format_args(input []FnCallArg) string {
+	args := make([]string, 0, len(input))
 
 	for _, arg := range input {
-		args = append(args, arg.Value)
+		args = append(args, FormatValue(arg.Value))
 	}
 
-	fmt.Println(args...)
-	return nil
+	return strings.Join(args, " ")
+}
+
+// Returns the name of the type of a runtime value as written in the source
+func value_type_name(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "()"
+	case int64:
+		return ast.INTEGER
+	case float64:
+		return ast.FLOAT
+	case string:
+		return ast.STRING
+	case bool:
+		return ast.BOOL
+	case []any:
+		return ast.ARRAY
+	case func(args ...FnCallArg) any:
+		return ast.FUNCTION
+	case struct_value:
+		return value.type_name()
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// FormatValue returns the textual representation of a runtime value
+func FormatValue(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "()"
+	case string:
+		return value
+	case func(args ...FnCallArg) any:
+		return "<fn>"
+	case struct_value:
+		names := slices.Sorted(maps.Keys(value.Fields))
+		fields := make([]string, 0, len(names))
+		for _, name := range names {
+			fields = append(fields, fmt.Sprintf("%s: %s", name, FormatValue(value.Fields[name])))
+		}
+		return fmt.Sprintf("%s{%s}", value.Name, strings.Join(fields, ", "))
+	case []any:
+		var builder strings.Builder
+		builder.WriteString("[")
+		for i, el := range value {
+			if i > 0 {
+				builder.WriteString(", ")
+			}
+			builder.WriteString(FormatValue(el))
+		}
+		builder.WriteString("]")
+		return builder.String()
+	default:
+		return fmt.Sprint(value)
+	}
 }
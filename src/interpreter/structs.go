@@ -0,0 +1,199 @@
+package interpreter
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/lucaengelhard/lang/src/ast"
+)
+
+// Instance of a struct or a record, Name is the struct it was created from and empty for records
+type struct_value struct {
+	Name   string
+	Fields map[string]any
+}
+
+func interpret_struct_instantiation(expr ast.StructInstantiationExpr, env *env) struct_value {
+	fields := make(map[string]any, len(expr.Properties))
+
+	for _, name := range slices.Sorted(maps.Keys(expr.Properties)) {
+		fields[name], _ = interpret(expr.Properties[name], env)
+	}
+
+	return struct_value{Name: expr.StructIdentifier, Fields: fields}
+}
+
+func interpret_record_instantiation(expr ast.RecordInstantiationExpr, env *env) struct_value {
+	fields := make(map[string]any, len(expr.Properties))
+
+	for _, prop := range expr.Properties {
+		fields[prop.Name], _ = interpret(prop.Value, env)
+	}
+
+	return struct_value{Fields: fields}
+}
+
+func (instance struct_value) type_name() string {
+	if instance.Name == "" {
+		return "record"
+	}
+
+	return instance.Name
+}
+
+func interpret_chain_expr(expr ast.ChainExpr, env *env) any {
+	if enum, members, is_enum := env.get_enum(expr.Assignee); is_enum {
+		return interpret_enum_member(expr, enum, members, env)
+	}
+
+	value, _ := interpret(expr.Assignee, env)
+	return get_member(env, expr.Position, value, expr.Member)
+}
+
+func get_member(env *env, pos ast.Position, value any, member string) any {
+	instance, ok := value.(struct_value)
+
+	if !ok {
+		env.throw(pos, "Can't access property %s on %s", member, FormatValue(value))
+	}
+
+	field, exists := instance.Fields[member]
+
+	if !exists {
+		env.throw(pos, "Property %s doesn't exist on %s", member, instance.type_name())
+	}
+
+	return field
+}
+
+// Resolves the function called by a.foo(). A function stored in the property
+// foo of a is called as is, otherwise foo is called with a as its first
+// argument, bound by reference or by value as foo expects.
+func (env *env) member_caller(chain ast.ChainExpr) (any, []FnCallArg) {
+	if _, _, is_enum := env.get_enum(chain.Assignee); is_enum {
+		return interpret_chain_expr(chain, env), nil
+	}
+
+	value, _ := interpret(chain.Assignee, env)
+
+	if instance, ok := value.(struct_value); ok {
+		if field, exists := instance.Fields[chain.Member]; exists {
+			return field, nil
+		}
+	}
+
+	declaration, err := env.get(chain.Member)
+	if err != nil {
+		return get_member(env, chain.Position, value, chain.Member), nil
+	}
+
+	if _, is_function := declaration.Value.(func(args ...FnCallArg) any); !is_function {
+		return get_member(env, chain.Position, value, chain.Member), nil
+	}
+
+	receiver := FnCallArg{Value: value, Receiver: true, Position: chain.Assignee.Pos()}
+	if symbol, is_symbol := chain.Assignee.(ast.SymbolExpr); is_symbol {
+		receiver.Reference, _ = env.get(symbol.Value)
+	}
+
+	return declaration.Value, []FnCallArg{receiver}
+}
+
+func properties_type(name string, properties map[string]ast.StructProperty) ast.Type {
+	arguments := make([]ast.Type, 0, len(properties))
+
+	for _, prop := range properties {
+		arguments = append(arguments, ast.Type{Name: prop.Name, Arguments: []ast.Type{prop.Type}})
+	}
+
+	return ast.Type{Name: name, Arguments: arguments}
+}
+
+// Types are only kept at runtime to match values against them
+func interpret_struct_stmt(stmt ast.StructStmt, env *env) {
+	env.define_type(stmt.Identifier, properties_type(ast.STRUCT, stmt.Properties))
+}
+
+func interpret_interface_stmt(stmt ast.InterfaceStmt, env *env) {
+	if !stmt.SingleType.IsUnset() {
+		env.define_type(stmt.Identifier, stmt.SingleType)
+		return
+	}
+
+	env.define_type(stmt.Identifier, properties_type(ast.DICT, stmt.StructType))
+}
+
+func (env *env) define_type(name string, t ast.Type) {
+	root := env.get_root()
+
+	if root.types == nil {
+		root.types = map[string]ast.Type{}
+	}
+
+	root.types[name] = t
+}
+
+var base_types = []string{ast.INTEGER, ast.FLOAT, ast.BOOL, ast.STRING, ast.ANY}
+
+func (env *env) is_type_name(name string) bool {
+	_, exists := env.get_root().types[name]
+	return exists || slices.Contains(base_types, name)
+}
+
+// Returns whether a value is of a type as written in the source. Structs
+// are matched by name, interfaces by their properties.
+func (env *env) value_is(value any, t ast.Type) bool {
+	switch t.Name {
+	case ast.ANY:
+		return true
+	case ast.INTEGER:
+		_, ok := value.(int64)
+		return ok
+	case ast.FLOAT:
+		_, ok := value.(float64)
+		return ok
+	case ast.STRING:
+		_, ok := value.(string)
+		return ok
+	case ast.BOOL:
+		_, ok := value.(bool)
+		return ok
+	case ast.ARRAY:
+		_, ok := value.([]any)
+		return ok
+	case ast.FUNCTION:
+		_, ok := value.(func(args ...FnCallArg) any)
+		return ok
+	case ast.UNION:
+		return slices.ContainsFunc(t.Arguments, func(member ast.Type) bool { return env.value_is(value, member) })
+	}
+
+	defined, exists := env.get_root().types[t.Name]
+	if !exists {
+		return false
+	}
+
+	switch defined.Name {
+	case ast.ENUM:
+		return env.is_enum_value(t.Name, value)
+	case ast.STRUCT:
+		instance, ok := value.(struct_value)
+		return ok && instance.Name == t.Name
+	case ast.DICT:
+		instance, ok := value.(struct_value)
+		if !ok {
+			return false
+		}
+
+		for _, prop := range defined.Arguments {
+			field, exists := instance.Fields[prop.Name]
+			if !exists || !env.value_is(field, prop.Arguments[0]) {
+				return false
+			}
+		}
+
+		return true
+	default:
+		return env.value_is(value, defined)
+	}
+}
@@ -0,0 +1,44 @@
+package interpreter_test
+
+import (
+	"testing"
+
+	"github.com/lucaengelhard/lang/src/errorhandling"
+)
+
+const point = `struct P { x: int; y: int; }`
+
+func TestStructFields(t *testing.T) {
+	expect_value(t, point+`let p = P { x: 1, y: 2 }; p.x + p.y;`, "3")
+	expect_value(t, point+`let p = P { y: 2, x: 1 }; p;`, "P{x: 1, y: 2}")
+	expect_value(t, `struct F { f: (x: int) -> int; } let s = F { f: fn (x: int) -> int { return x + 1; } }; s.f(1);`, "2")
+}
+
+func TestStructsAreCopiedOnAssignment(t *testing.T) {
+	expect_value(t, point+`let mut p = P { x: 1, y: 2 }; let q = p; p.x = 5; q.x;`, "1")
+	expect_value(t, `struct I { v: int; } struct O { i: I; } let mut o = O { i: I { v: 1 } }; let c = o; o.i.v = 2; c.i.v;`, "1")
+	expect_value(t, `struct A { xs: Array<int>; } let mut a = A { xs: [1] }; let b = a; a.xs[0] = 2; b.xs;`, "[1]")
+}
+
+func TestStructsAreCopiedWhenPassedByValue(t *testing.T) {
+	expect_value(t, point+`fn f(mut p: P) -> int { p.x = 9; return p.x; } let p = P { x: 1, y: 2 }; f(p) * 10 + p.x;`, "91")
+}
+
+func TestStructsAreMutatedThroughMutableReferences(t *testing.T) {
+	expect_value(t, point+`fn set(mut p: *P) { p.x = 5; } let mut q = P { x: 1, y: 2 }; set(&q); q.x;`, "5")
+	expect_error(t, point+`fn set(p: *P) { p.x = 5; }`, errorhandling.TYPE, "Can't assign to p.x, p is not mutable")
+	expect_error(t, point+`let p = P { x: 1, y: 2 }; p.x = 2;`, errorhandling.TYPE, "Can't assign to p.x, p is not mutable")
+}
+
+func TestStructFieldErrors(t *testing.T) {
+	expect_error(t, point+`let mut p = P { x: 1, y: 2 }; p.x = "s";`, errorhandling.TYPE, "Type string is not assignable to p.x of type int")
+	expect_error(t, point+`let p = P { x: 1, y: 2 }; p.z;`, errorhandling.TYPE, "Property z doesn't exist on Struct<x<int>, y<int>>")
+	expect_unchecked_error(t, point+`let p = P { x: 1, y: 2 }; p.z;`, "Property z doesn't exist on P")
+}
+
+func TestStructConstructorErrors(t *testing.T) {
+	expect_error(t, point+`P { x: 1 };`, errorhandling.TYPE, "Property y missing on struct")
+	expect_error(t, point+`P { x: 1, y: "a" };`, errorhandling.TYPE, "Property y expected y<int> but got y<string>")
+	expect_error(t, point+`P { x: 1, y: 2, z: 3 };`, errorhandling.TYPE, "Property z doesn't exist on struct P")
+	expect_error(t, `Q { x: 1 };`, errorhandling.TYPE, "Type Q doesn't exist")
+}
@@ -0,0 +1,15 @@
+package interpreter_test
+
+import (
+	"testing"
+
+	"github.com/lucaengelhard/lang/src/errorhandling"
+)
+
+func TestTemplates(t *testing.T) {
+	expect_value(t, `let x = 2; "a {x} b {x + 1}";`, "a 2 b 3")
+	expect_value(t, `"{[1, 2]} {true} {1.5} {{a: 1}}";`, "[1, 2] true 1.5 {a: 1}")
+	expect_value(t, `let name = "b"; "a { "{name}!" } \{c\}";`, "a b! {c}")
+	expect_error(t, `let s: int = "a {1}";`, errorhandling.TYPE, "Type string doesn't match int (int)")
+	expect_error(t, `"a {y}";`, errorhandling.TYPE, "Variable y doesn't exist")
+}
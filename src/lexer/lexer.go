@@ -2,25 +2,25 @@ package lexer
 
 import (
 	"fmt"
-	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/lucaengelhard/lang/src/errorhandling"
+	"github.com/lucaengelhard/lang/src/lib"
 )
 
-type regexHandler func(lex *Lexer, regex *regexp.Regexp)
-
-type regexPattern struct {
-	regex   *regexp.Regexp
-	handler regexHandler
-}
-
 type Lexer struct {
-	patterns  []regexPattern
 	Tokens    []Token
 	source    string
 	pos       int
 	Errors    []errorhandling.Error
 	forceExit bool
+	// Identifiers are interned, so every occurrence of a name shares one string
+	identifiers map[string]string
+	lines       *lib.LineCursor
+	// Strings whose embedded expression is being scanned, innermost last
+	templates []template
 }
 
 func (lex *Lexer) advanceN(n int) {
@@ -31,6 +31,12 @@ func (lex *Lexer) push(token Token) {
 	lex.Tokens = append(lex.Tokens, token)
 }
 
+// Pushes a token spanning the source from start to end
+func (lex *Lexer) emit(kind TokenKind, literal string, start int, end int) {
+	line, col := lex.lines.Locate(start)
+	lex.push(Token{Kind: kind, Literal: literal, Position: start, End: end, Line: line, Col: col})
+}
+
 func (lex *Lexer) remainder() string {
 	return lex.source[lex.pos:]
 }
@@ -39,10 +45,27 @@ func (lex *Lexer) at_eof() bool {
 	return lex.pos >= len(lex.source)
 }
 
+// Returns the byte at pos + offset or 0 if that is past the end of the source
+func (lex *Lexer) peek(offset int) byte {
+	if lex.pos+offset >= len(lex.source) {
+		return 0
+	}
+
+	return lex.source[lex.pos+offset]
+}
+
 func (lex *Lexer) err(message string) {
+	lex.err_at(lex.pos, lex.pos+1, message)
+}
+
+func (lex *Lexer) err_at(start int, end int, message string) {
+	end = min(end, len(lex.source))
 	lex.Errors = append(lex.Errors, errorhandling.Error{
-		Message:  "Lexer error -> " + message,
-		Position: lex.pos,
+		Message:      message,
+		Phase:        errorhandling.LEXER,
+		Position:     start,
+		End:          end,
+		TokenLiteral: lex.source[start:end],
 	})
 }
 
@@ -52,8 +75,12 @@ func (lex *Lexer) panic(message string) {
 }
 
 func (lex *Lexer) Print() {
-	fmt.Printf("[Lexer]: %v Tokens\n", len(lex.Tokens))
-	for _, t := range lex.Tokens {
+	PrintTokens(lex.Tokens)
+}
+
+func PrintTokens(tokens []Token) {
+	fmt.Printf("[Lexer]: %v Tokens\n", len(tokens))
+	for _, t := range tokens {
 		fmt.Printf("%s -> %s\n", t.Kind.ToString(), t.Literal)
 	}
 }
@@ -62,142 +89,184 @@ func Tokenize(source string) ([]Token, []errorhandling.Error) {
 	lex := createLexer(source)
 
 	for !lex.at_eof() && !lex.forceExit {
-		matched := false
-		for _, pattern := range lex.patterns {
-			location := pattern.regex.FindStringIndex(lex.remainder())
-			if location != nil && location[0] == 0 {
-				pattern.handler(lex, pattern.regex)
-				matched = true
-				break
-			}
-		}
-		if !matched {
-			lex.panic(fmt.Sprintf("unrecognized token at %s", lex.remainder()))
+		lex.scan()
+	}
+
+	for _, template := range lex.templates {
+		if lex.forceExit {
+			break
 		}
+
+		lex.err_at(template.start, template.start+1, "unterminated embedded expression in string")
 	}
 
-	lex.push(NewToken(EOF, "EOF", lex.pos))
+	lex.emit(EOF, "EOF", lex.pos, lex.pos)
 	return lex.Tokens, lex.Errors
 }
 
 func createLexer(source string) *Lexer {
 	InitTokenLookup()
-	return &Lexer{pos: 0, source: source, Tokens: make([]Token, 0), Errors: make([]errorhandling.Error, 0), patterns: []regexPattern{
-		{regexp.MustCompile(`\s+`), skipHandler},
-		{regexp.MustCompile(`\/\/.*`), skipHandler},
-		{regexp.MustCompile(`\/\*[\s\S]*?\*\/`), skipHandler},
-		{regexp.MustCompile(`"(?:[^"\\]|\\.)*"`), stringHandler},
-		{regexp.MustCompile(`[0-9]+(\.[0-9]+)?`), numberHandler},
-		{regexp.MustCompile(`[a-zA-Z_][a-zA-Z0-9_]*`), symbolHandler},
-		{regexp.MustCompile(`\[`), defaultHandler(OPEN_BRACKET, "[")},
-		{regexp.MustCompile(`\]`), defaultHandler(CLOSE_BRACKET, "]")},
-		{regexp.MustCompile(`\{`), defaultHandler(OPEN_CURLY, "{")},
-		{regexp.MustCompile(`\}`), defaultHandler(CLOSE_CURLY, "}")},
-		{regexp.MustCompile(`\(`), defaultHandler(OPEN_PAREN, "(")},
-		{regexp.MustCompile(`\)`), defaultHandler(CLOSE_PAREN, ")")},
-		{regexp.MustCompile(`==`), defaultHandler(EQUALS, "==")},
-		{regexp.MustCompile(`!=`), defaultHandler(NOT_EQUALS, "!=")},
-		{regexp.MustCompile(`=`), defaultHandler(ASSIGNMENT, "=")},
-		{regexp.MustCompile(`!`), defaultHandler(NOT, "!")},
-		{regexp.MustCompile(`<-`), defaultHandler(L_ARROW, "<-")},
-		{regexp.MustCompile(`->`), defaultHandler(R_ARROW, "->")},
-		{regexp.MustCompile(`<=`), defaultHandler(LESS_EQUALS, "<=")},
-		{regexp.MustCompile(`<`), defaultHandler(LESS, "<")},
-		{regexp.MustCompile(`>=`), defaultHandler(GREATER_EQUALS, ">=")},
-		{regexp.MustCompile(`>`), defaultHandler(GREATER, ">")},
-		{regexp.MustCompile(`\|\|`), defaultHandler(OR, "||")},
-		{regexp.MustCompile(`&&`), defaultHandler(AND, "&&")},
-		{regexp.MustCompile(`\.\.\.`), defaultHandler(SPREAD, "...")},
-		{regexp.MustCompile(`\.`), defaultHandler(DOT, ".")},
-		{regexp.MustCompile(`;`), defaultHandler(SEMI_COLON, ";")},
-		{regexp.MustCompile(`:`), defaultHandler(COLON, ":")},
-		{regexp.MustCompile(`\?`), defaultHandler(QUESTION, "?")},
-		{regexp.MustCompile(`,`), defaultHandler(COMMA, ",")},
-		{regexp.MustCompile(`\+\+`), defaultHandler(PLUS_PLUS, "++")},
-		{regexp.MustCompile(`--`), defaultHandler(MINUS_MINUS, "--")},
-		{regexp.MustCompile(`\+=`), defaultHandler(PLUS_EQUALS, "+=")},
-		{regexp.MustCompile(`-=`), defaultHandler(MINUS_EQUALS, "-=")},
-		{regexp.MustCompile(`\+`), defaultHandler(PLUS, "+")},
-		{regexp.MustCompile(`-`), defaultHandler(MINUS, "-")},
-		{regexp.MustCompile(`/`), defaultHandler(SLASH, "/")},
-		{regexp.MustCompile(`\*`), defaultHandler(STAR, "*")},
-		{regexp.MustCompile(`%`), defaultHandler(PERCENT, "%")},
-		{regexp.MustCompile(`&`), defaultHandler(AMPERSAND, "%")},
-	}}
-}
-
-func defaultHandler(kind TokenKind, value string) regexHandler {
-	return func(lex *Lexer, regex *regexp.Regexp) {
-		lex.advanceN(len(value))
-		lex.push(NewToken(kind, value, lex.pos))
-	}
-}
-
-func numberHandler(lex *Lexer, regex *regexp.Regexp) {
-	match := regex.FindString(lex.remainder())
-
-	lex.push(NewToken(NUMBER, match, lex.pos))
-	lex.advanceN(len(match))
-}
-
-func stringHandler(lex *Lexer, regex *regexp.Regexp) {
-	match := regex.FindStringIndex(lex.remainder())
-	literal := lex.remainder()[match[0]:match[1]]
-
-	/*
-		unqouted, _ := strconv.Unquote(literal)
-
-		format := regexp.MustCompile(`{(.*?)}`).FindAllStringIndex(unqouted, -1)
-
-		strs := make([]string, 0)
-		sub_exprs := make([][]Token, 0)
-
-		var prev = 0
-		for _, format_match := range format {
-			left := unqouted[prev:format_match[0]]
-			strs = append(strs, left)
-			prev = format_match[1]
-
-			substr := unqouted[format_match[0]+1 : format_match[1]-1]
-			sub_exprs = append(sub_exprs, Tokenize(substr, false))
-		}
+	return &Lexer{
+		pos:         0,
+		source:      source,
+		Tokens:      make([]Token, 0, len(source)/4),
+		Errors:      make([]errorhandling.Error, 0),
+		identifiers: map[string]string{},
+		lines:       lib.NewLineTable(source).Cursor(),
+	}
+}
+
+// Returns the rune at the current position and its size in bytes
+func (lex *Lexer) current_rune() (rune, int) {
+	if c := lex.source[lex.pos]; c < utf8.RuneSelf {
+		return rune(c), 1
+	}
+
+	return utf8.DecodeRuneInString(lex.remainder())
+}
+
+// Scans a single token (or skips whitespace and comments) at the current position
+func (lex *Lexer) scan() {
+	c := lex.source[lex.pos]
+	r, _ := lex.current_rune()
+
+	switch {
+	case is_whitespace(c):
+		lex.skip_whitespace()
+	case c == '/' && lex.peek(1) == '/':
+		lex.skip_line_comment()
+	case c == '/' && lex.peek(1) == '*':
+		lex.skip_block_comment()
+	case c == '"':
+		lex.scan_string()
+	case c == '`':
+		lex.scan_raw_string()
+	case (c == '{' || c == '}') && len(lex.templates) > 0:
+		lex.scan_template_curly()
+	case is_digit(c):
+		lex.scan_number()
+	case is_identifier_start(r):
+		lex.scan_identifier()
+	default:
+		lex.scan_symbol()
+	}
+}
 
-		strs = append(strs, unqouted[prev:])
+func (lex *Lexer) skip_whitespace() {
+	for !lex.at_eof() && is_whitespace(lex.source[lex.pos]) {
+		lex.pos++
+	}
+}
 
-		lex.push(NewToken(STRING, strs[0], lex.get_file_pos()))
+func (lex *Lexer) skip_line_comment() {
+	end := strings.IndexByte(lex.remainder(), '\n')
 
-		for i, expr := range sub_exprs {
-			lex.push(NewToken(PLUS, "+", lex.get_file_pos()))
-			lex.push(NewToken(OPEN_PAREN, "(", lex.get_file_pos()))
-			for _, tok := range expr {
-				if tok.Kind != EOF {
-					lex.push(tok)
-				}
-			}
+	if end < 0 {
+		lex.pos = len(lex.source)
+		return
+	}
 
-			lex.push(NewToken(CLOSE_PAREN, ")", lex.get_file_pos()))
+	lex.advanceN(end)
+}
 
-			lex.push(NewToken(PLUS, "+", lex.get_file_pos()))
+func (lex *Lexer) skip_block_comment() {
+	end := strings.Index(lex.remainder()[2:], "*/")
 
-			lex.push(NewToken(STRING, strs[i+1], lex.get_file_pos()))
-		} */
+	if end < 0 {
+		lex.panic("unterminated block comment")
+		return
+	}
 
-	lex.push(NewToken(STRING, literal, lex.pos))
-	lex.advanceN(len(literal))
+	lex.advanceN(end + 4)
 }
 
-func symbolHandler(lex *Lexer, regex *regexp.Regexp) {
-	match := regex.FindString(lex.remainder())
-	if kind, exists := reserved_lookup[match]; exists {
-		lex.push(NewToken(kind, match, lex.pos))
+func (lex *Lexer) scan_identifier() {
+	start := lex.pos
+	lex.skip_identifier()
+	literal := lex.source[start:lex.pos]
+
+	if kind, exists := reserved_lookup[literal]; exists {
+		lex.emit(kind, literal, start, lex.pos)
+		return
+	}
+
+	lex.emit(IDENTIFIER, lex.intern(literal), start, lex.pos)
+}
+
+func (lex *Lexer) skip_identifier() {
+	for !lex.at_eof() {
+		r, size := lex.current_rune()
+		if !is_identifier_part(r) {
+			return
+		}
+
+		lex.pos += size
+	}
+}
+
+func (lex *Lexer) intern(identifier string) string {
+	if interned, exists := lex.identifiers[identifier]; exists {
+		return interned
+	}
+
+	lex.identifiers[identifier] = identifier
+	return identifier
+}
+
+// Symbols are matched longest first, so "..." wins over "." and "+=" over "+"
+func (lex *Lexer) scan_symbol() {
+	for length := max_symbol_length; length > 0; length-- {
+		if lex.pos+length > len(lex.source) {
+			continue
+		}
+
+		literal := lex.source[lex.pos : lex.pos+length]
+
+		if kind, exists := symbol_lookup[literal]; exists {
+			lex.emit(kind, literal, lex.pos, lex.pos+length)
+			lex.advanceN(length)
+			return
+		}
+	}
+
+	r, size := lex.current_rune()
+
+	if r == utf8.RuneError && size == 1 {
+		lex.err("invalid UTF-8 encoding")
 	} else {
-		lex.push(NewToken(IDENTIFIER, match, lex.pos))
+		lex.err_at(lex.pos, lex.pos+size, fmt.Sprintf("unrecognized character %q", r))
+	}
+
+	lex.advanceN(size)
+}
+
+func is_whitespace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+func is_digit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func is_hex_digit(c byte) bool {
+	return is_digit(c) || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+// Identifiers follow UAX #31 (https://unicode.org/reports/tr31/) with "_" as an additional start character
+func is_identifier_start(r rune) bool {
+	if r < utf8.RuneSelf {
+		return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '_'
 	}
 
-	lex.advanceN(len(match))
+	return unicode.In(r, unicode.L, unicode.Nl, unicode.Other_ID_Start) &&
+		!unicode.In(r, unicode.Pattern_Syntax, unicode.Pattern_White_Space)
 }
 
-func skipHandler(lex *Lexer, regex *regexp.Regexp) {
-	match := regex.FindStringIndex(lex.remainder())
-	lex.advanceN(match[1])
+func is_identifier_part(r rune) bool {
+	if r < utf8.RuneSelf {
+		return is_identifier_start(r) || is_digit(byte(r))
+	}
+
+	return is_identifier_start(r) || unicode.In(r, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc, unicode.Other_ID_Continue) &&
+		!unicode.In(r, unicode.Pattern_Syntax, unicode.Pattern_White_Space)
 }
@@ -0,0 +1,246 @@
+package lexer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+func tokenize(t *testing.T, source string) []Token {
+	t.Helper()
+	tokens, errors := Tokenize(source)
+
+	if len(errors) > 0 {
+		t.Fatalf("unexpected errors for %q: %v", source, errors)
+	}
+
+	return tokens
+}
+
+func kinds(tokens []Token) []TokenKind {
+	result := make([]TokenKind, 0, len(tokens))
+	for _, token := range tokens {
+		result = append(result, token.Kind)
+	}
+	return result
+}
+
+func expect_kinds(t *testing.T, source string, expected ...TokenKind) []Token {
+	t.Helper()
+	tokens := tokenize(t, source)
+	expected = append(expected, EOF)
+
+	if fmt.Sprint(kinds(tokens)) != fmt.Sprint(expected) {
+		t.Fatalf("tokens of %q: expected %v, got %v", source, expected, kinds(tokens))
+	}
+
+	return tokens
+}
+
+func TestStatement(t *testing.T) {
+	tokens := expect_kinds(t, "let mut a = b + 1;", LET, MUT, IDENTIFIER, ASSIGNMENT, IDENTIFIER, PLUS, NUMBER, SEMI_COLON)
+
+	literals := []string{"let", "mut", "a", "=", "b", "+", "1", ";"}
+	for i, literal := range literals {
+		if tokens[i].Literal != literal {
+			t.Errorf("token %d: expected literal %q, got %q", i, literal, tokens[i].Literal)
+		}
+	}
+}
+
+func TestLongestSymbolWins(t *testing.T) {
+	expect_kinds(t, "a += ...b => c == d != e <= f", IDENTIFIER, PLUS_EQUALS, SPREAD, IDENTIFIER, FAT_ARROW, IDENTIFIER, EQUALS, IDENTIFIER, NOT_EQUALS, IDENTIFIER, LESS_EQUALS, IDENTIFIER)
+	expect_kinds(t, "a++-b", IDENTIFIER, PLUS_PLUS, MINUS, IDENTIFIER)
+}
+
+func TestKeywordsAndIdentifiers(t *testing.T) {
+	expect_kinds(t, "fn fnord if iffy return returns", FN, IDENTIFIER, IF, IDENTIFIER, RETURN, IDENTIFIER)
+}
+
+func TestCommentsAreSkipped(t *testing.T) {
+	expect_kinds(t, "a // comment\n/* block\ncomment */ b", IDENTIFIER, IDENTIFIER)
+}
+
+func TestPositions(t *testing.T) {
+	tokens := tokenize(t, "let a = 1;\n  foo(a);")
+	foo := tokens[5]
+
+	if foo.Literal != "foo" || foo.Position != 13 || foo.End != 16 || foo.Line != 2 || foo.Col != 3 {
+		t.Errorf("unexpected position of foo: %+v", foo)
+	}
+}
+
+func TestUnicodeColumnsCountRunes(t *testing.T) {
+	tokens := tokenize(t, "let äöü = größe;")
+	size := tokens[3]
+
+	if size.Literal != "größe" || size.Col != 11 {
+		t.Errorf("expected größe at column 11, got %+v", size)
+	}
+}
+
+func TestIdentifiersAreInterned(t *testing.T) {
+	tokens := tokenize(t, "abc + abc")
+
+	if unsafe.StringData(tokens[0].Literal) != unsafe.StringData(tokens[2].Literal) {
+		t.Errorf("expected both identifiers to share their literal")
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	for _, source := range []string{"0", "42", "1_000_000", "0xff", "0b1010", "0o17", "1.5", "1.5e-3", "2E10"} {
+		tokens := expect_kinds(t, source, NUMBER)
+
+		if tokens[0].Literal != source {
+			t.Errorf("expected literal %q, got %q", source, tokens[0].Literal)
+		}
+	}
+}
+
+func TestMalformedNumbers(t *testing.T) {
+	for _, source := range []string{"1__0", "1_", "0xg", "0b2", "12abc", "9223372036854775808"} {
+		if _, errors := Tokenize(source); len(errors) == 0 {
+			t.Errorf("expected an error for %q", source)
+		}
+	}
+}
+
+func TestStrings(t *testing.T) {
+	tokens := expect_kinds(t, `"a\tb\n\"c\""`, STRING)
+
+	if tokens[0].Literal != "a\tb\n\"c\"" {
+		t.Errorf("expected escapes to be decoded, got %q", tokens[0].Literal)
+	}
+
+	tokens = expect_kinds(t, "`raw\\n`", STRING)
+
+	if tokens[0].Literal != `raw\n` {
+		t.Errorf("expected raw string to be kept as is, got %q", tokens[0].Literal)
+	}
+}
+
+func TestUnterminatedString(t *testing.T) {
+	if _, errors := Tokenize(`"abc`); len(errors) == 0 {
+		t.Errorf("expected an error for an unterminated string")
+	}
+}
+
+func TestUnrecognizedCharacter(t *testing.T) {
+	tokens, errors := Tokenize("a § b")
+
+	if len(errors) != 1 {
+		t.Fatalf("expected one error, got %v", errors)
+	}
+
+	if fmt.Sprint(kinds(tokens)) != fmt.Sprint([]TokenKind{IDENTIFIER, IDENTIFIER, EOF}) {
+		t.Errorf("expected scanning to continue after the error, got %v", kinds(tokens))
+	}
+}
+
+// Generates a source of roughly size bytes from a mix of statements
+func generate_source(size int) string {
+	var builder strings.Builder
+	builder.Grow(size + 128)
+
+	for i := 0; builder.Len() < size; i++ {
+		fmt.Fprintf(&builder, "let value_%d = foo(%d, \"str\\n\") + 2.5e3 * 0xff; // comment\n", i, i)
+		fmt.Fprintf(&builder, "fn bar_%d(a: int, mut b: *Array<int>) -> int { return a += b[0]; }\n", i)
+		builder.WriteString("/* block\n   comment */ let größe = `raw`;\n")
+	}
+
+	return builder.String()
+}
+
+// The throughput should stay the same for every size if scanning is linear
+func BenchmarkTokenize(b *testing.B) {
+	for _, megabytes := range []int{1, 4, 16} {
+		source := generate_source(megabytes << 20)
+
+		b.Run(fmt.Sprintf("%dMB", megabytes), func(b *testing.B) {
+			b.SetBytes(int64(len(source)))
+
+			for b.Loop() {
+				if _, errors := Tokenize(source); len(errors) > 0 {
+					b.Fatal(errors[0].Message)
+				}
+			}
+		})
+	}
+}
+
+func expect_string(t *testing.T, source string, expected string) {
+	t.Helper()
+	tokens := expect_kinds(t, source, STRING)
+
+	if tokens[0].Literal != expected {
+		t.Errorf("expected %q to decode to %q, got %q", source, expected, tokens[0].Literal)
+	}
+}
+
+// Expects exactly one error with the given message at the given source
+func expect_lexer_error(t *testing.T, source string, message string, token string) {
+	t.Helper()
+	_, errors := Tokenize(source)
+
+	if len(errors) != 1 || errors[0].Message != message || errors[0].TokenLiteral != token {
+		t.Errorf("%q: expected the error %q at %q, got %v", source, message, token, errors)
+	}
+}
+
+func TestUnicodeEscapes(t *testing.T) {
+	expect_string(t, `"\u{41}\u{e9}\u{1F600}"`, "Aé😀")
+	expect_string(t, `"\u{000041}"`, "A")
+}
+
+func TestInvalidEscapes(t *testing.T) {
+	expect_lexer_error(t, `"\q"`, `invalid escape sequence \q`, `\q`)
+	expect_lexer_error(t, `"\u41"`, `unicode escapes must be written as \u{XXXX}`, `\u`)
+	expect_lexer_error(t, `"\u{41"`, "unterminated unicode escape", `\u{`)
+	expect_lexer_error(t, `"\u{zz}"`, `invalid unicode escape \u{zz}`, `\u{zz}`)
+	expect_lexer_error(t, `"\u{1234567}"`, `invalid unicode escape \u{1234567}`, `\u{1234567}`)
+	expect_lexer_error(t, `"\u{110000}"`, `unicode escape \u{110000} is not a valid code point`, `\u{110000}`)
+	expect_lexer_error(t, `"\u{D800}"`, `unicode escape \u{D800} is not a valid code point`, `\u{D800}`)
+}
+
+func TestMultilineStrings(t *testing.T) {
+	expect_string(t, `"""one"""`, "one")
+	expect_string(t, "\"\"\"\n    first\n      indented\n\n    last\n    \"\"\"", "first\n  indented\n\nlast")
+	expect_string(t, "\"\"\"\r\n  a\r\n  b\r\n  \"\"\"", "a\nb")
+	expect_string(t, "\"\"\"\n  a\\tb\\n\n  \"\"\"", "a\tb\n")
+	expect_string(t, "\"\"\"\n  a \\\n  b\n  \"\"\"", "a b")
+	expect_string(t, "\"\"\"\n  a \\\\\n  b\n  \"\"\"", "a \\\nb")
+	expect_string(t, "\"\"\"\n  say \"\"hi\\\"\"\"\n  \"\"\"", `say ""hi"""`)
+	expect_lexer_error(t, "\"\"\"x", "unterminated multi-line string literal", "\"\"\"x")
+}
+
+func TestCRLFLineBreaks(t *testing.T) {
+	tokens := tokenize(t, "a\r\n  b\r\n\r\nc\rd")
+	expected := [][2]int{{1, 1}, {2, 3}, {4, 1}, {4, 3}}
+
+	for i, position := range expected {
+		if tokens[i].Line != position[0] || tokens[i].Col != position[1] {
+			t.Errorf("%s: expected %d:%d, got %d:%d", tokens[i].Literal, position[0], position[1], tokens[i].Line, tokens[i].Col)
+		}
+	}
+}
+
+func TestUnicodeIdentifiers(t *testing.T) {
+	tokens := expect_kinds(t, "αβγ ñ 名前 éx _a1 a·b a٣", IDENTIFIER, IDENTIFIER, IDENTIFIER, IDENTIFIER, IDENTIFIER, IDENTIFIER, IDENTIFIER)
+	columns := []int{1, 5, 7, 10, 14, 18, 22}
+
+	for i, col := range columns {
+		if tokens[i].Col != col {
+			t.Errorf("%s: expected column %d, got %d", tokens[i].Literal, col, tokens[i].Col)
+		}
+	}
+}
+
+func TestCharactersThatCantStartIdentifiers(t *testing.T) {
+	// Digits, emoji, pattern syntax and white space outside of ASCII
+	expect_lexer_error(t, "٣", "unrecognized character '٣'", "٣")
+	expect_lexer_error(t, "😀", "unrecognized character '😀'", "😀")
+	expect_lexer_error(t, "a→b", "unrecognized character '→'", "→")
+	expect_lexer_error(t, "x\u00a0y", `unrecognized character '\u00a0'`, "\u00a0")
+	expect_lexer_error(t, "\u0301", "unrecognized character '\u0301'", "\u0301")
+}
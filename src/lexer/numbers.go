@@ -0,0 +1,150 @@
+package lexer
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Number tokens keep the literal as written. ParseInt and ParseFloat turn
+// them into values, the lexer has already reported literals they reject.
+
+var base_prefix_lookup = map[byte]int{
+	'x': 16, 'X': 16,
+	'o': 8, 'O': 8,
+	'b': 2, 'B': 2,
+}
+
+var base_name_lookup = map[int]string{
+	16: "hexadecimal",
+	10: "decimal",
+	8:  "octal",
+	2:  "binary",
+}
+
+// Splits a literal into its base and its digits without the base prefix
+func number_base(literal string) (int, string) {
+	if len(literal) > 2 && literal[0] == '0' {
+		if base, exists := base_prefix_lookup[literal[1]]; exists {
+			return base, literal[2:]
+		}
+	}
+
+	return 10, literal
+}
+
+// Returns whether a number literal has a fraction or an exponent
+func IsFloatLiteral(literal string) bool {
+	base, _ := number_base(literal)
+	return base == 10 && strings.ContainsAny(literal, ".eE")
+}
+
+func ParseInt(literal string) (int64, error) {
+	base, digits := number_base(literal)
+	return strconv.ParseInt(strings.ReplaceAll(digits, "_", ""), base, 64)
+}
+
+func ParseFloat(literal string) (float64, error) {
+	return strconv.ParseFloat(strings.ReplaceAll(literal, "_", ""), 64)
+}
+
+func is_digit_of(base int, c byte) bool {
+	switch base {
+	case 16:
+		return is_hex_digit(c)
+	case 8:
+		return c >= '0' && c <= '7'
+	case 2:
+		return c == '0' || c == '1'
+	default:
+		return is_digit(c)
+	}
+}
+
+func (lex *Lexer) scan_number() {
+	start := lex.pos
+	base := 10
+	reported := len(lex.Errors)
+
+	if prefix, exists := base_prefix_lookup[lex.peek(1)]; exists && lex.peek(0) == '0' {
+		base = prefix
+		lex.advanceN(2)
+	}
+
+	lex.scan_digits(base)
+
+	if base == 10 {
+		if lex.peek(0) == '.' && is_digit(lex.peek(1)) {
+			lex.pos++
+			lex.scan_digits(base)
+		}
+
+		if lex.peek(0) == 'e' || lex.peek(0) == 'E' {
+			sign := 0
+			if lex.peek(1) == '+' || lex.peek(1) == '-' {
+				sign = 1
+			}
+
+			if is_digit(lex.peek(1 + sign)) {
+				lex.advanceN(1 + sign)
+				lex.scan_digits(base)
+			}
+		}
+	}
+
+	// Letters and digits directly after a number belong to it, but not to its base
+	suffix := lex.pos
+	lex.skip_identifier()
+
+	literal := lex.source[start:lex.pos]
+	lex.emit(NUMBER, literal, start, lex.pos)
+
+	if suffix < lex.pos {
+		if len(lex.Errors) > reported {
+			return
+		}
+
+		lex.err_at(suffix, lex.pos, fmt.Sprintf("invalid %s literal %s", base_name_lookup[base], literal))
+		return
+	}
+
+	lex.check_number(start, literal)
+}
+
+// Scans digits of the base separated by single underscores
+func (lex *Lexer) scan_digits(base int) {
+	start := lex.pos
+
+	for !lex.at_eof() {
+		c := lex.source[lex.pos]
+
+		if c == '_' {
+			if lex.pos == start || !is_digit_of(base, lex.peek(1)) {
+				lex.err("underscores in numbers must separate digits")
+			}
+		} else if !is_digit_of(base, c) {
+			break
+		}
+
+		lex.pos++
+	}
+
+	if lex.pos == start {
+		lex.err(fmt.Sprintf("expected %s digits", base_name_lookup[base]))
+	}
+}
+
+func (lex *Lexer) check_number(start int, literal string) {
+	var err error
+
+	if IsFloatLiteral(literal) {
+		_, err = ParseFloat(literal)
+	} else {
+		_, err = ParseInt(literal)
+	}
+
+	if errors.Is(err, strconv.ErrRange) {
+		lex.err_at(start, lex.pos, fmt.Sprintf("number literal %s is out of range", literal))
+	}
+}
@@ -0,0 +1,329 @@
+package lexer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// String tokens span the literal in the source including its delimiters,
+// their literal is the decoded value.
+
+var escape_lookup = map[byte]byte{
+	'n':  '\n',
+	't':  '\t',
+	'r':  '\r',
+	'0':  0,
+	'"':  '"',
+	'\'': '\'',
+	'\\': '\\',
+	'{':  '{',
+	'}':  '}',
+}
+
+type template struct {
+	// Offset of the curly that opened the embedded expression
+	start int
+	// Curlies opened inside the embedded expression that are still open
+	depth int
+}
+
+func (lex *Lexer) scan_string() {
+	if strings.HasPrefix(lex.remainder(), `"""`) {
+		lex.scan_multiline_string()
+		return
+	}
+
+	start := lex.pos
+	lex.pos++
+	lex.scan_string_segment(start, STRING, TEMPLATE_HEAD)
+}
+
+// Scans the literal text of a string up to the closing quote, which emits
+// a token of kind closed, or up to an embedded expression, which emits a
+// token of kind opened.
+func (lex *Lexer) scan_string_segment(start int, closed TokenKind, opened TokenKind) {
+	content_start := lex.pos
+
+	for !lex.at_eof() {
+		switch lex.source[lex.pos] {
+		case '\\':
+			lex.skip_escape()
+		case '"':
+			lex.pos++
+			lex.emit(closed, lex.decode(content_start, lex.pos-1), start, lex.pos)
+			return
+		case '{':
+			lex.templates = append(lex.templates, template{start: lex.pos})
+			lex.pos++
+			lex.emit(opened, lex.decode(content_start, lex.pos-1), start, lex.pos)
+			return
+		default:
+			lex.pos++
+		}
+	}
+
+	lex.unterminated(start, "string literal")
+}
+
+// The curly of a unicode escape doesn't start an embedded expression
+func (lex *Lexer) skip_escape() {
+	if lex.peek(1) != 'u' || lex.peek(2) != '{' {
+		lex.pos += 2
+		return
+	}
+
+	lex.pos += 3
+	for !lex.at_eof() && is_hex_digit(lex.source[lex.pos]) {
+		lex.pos++
+	}
+
+	if lex.peek(0) == '}' {
+		lex.pos++
+	}
+}
+
+// Curlies inside an embedded expression are tokens, except for the one
+// closing it, after which the string continues
+func (lex *Lexer) scan_template_curly() {
+	template := &lex.templates[len(lex.templates)-1]
+
+	switch {
+	case lex.source[lex.pos] == '{':
+		template.depth++
+		lex.scan_symbol()
+	case template.depth > 0:
+		template.depth--
+		lex.scan_symbol()
+	default:
+		lex.templates = lex.templates[:len(lex.templates)-1]
+		start := lex.pos
+		lex.pos++
+		lex.scan_string_segment(start, TEMPLATE_TAIL, TEMPLATE_MIDDLE)
+	}
+}
+
+// Raw strings are taken as written, they can't contain backticks
+func (lex *Lexer) scan_raw_string() {
+	start := lex.pos
+	end := strings.IndexByte(lex.source[start+1:], '`')
+
+	if end < 0 {
+		lex.pos = len(lex.source)
+		lex.unterminated(start, "raw string literal")
+		return
+	}
+
+	lex.pos = start + 1 + end + 1
+	lex.emit(STRING, lex.source[start+1:lex.pos-1], start, lex.pos)
+}
+
+func (lex *Lexer) scan_multiline_string() {
+	start := lex.pos
+	lex.advanceN(3)
+
+	for !lex.at_eof() {
+		if lex.source[lex.pos] == '\\' {
+			lex.pos += 2
+			continue
+		}
+
+		if strings.HasPrefix(lex.remainder(), `"""`) {
+			content_end := lex.pos
+			lex.advanceN(3)
+			lex.emit(STRING, lex.dedent(start+3, content_end), start, lex.pos)
+			return
+		}
+
+		lex.pos++
+	}
+
+	lex.unterminated(start, "multi-line string literal")
+}
+
+// Inside an embedded expression that wasn't closed, the closing quote of the
+// string starts a new string that runs to the end of the source
+func (lex *Lexer) unterminated(start int, kind string) {
+	if len(lex.templates) > 0 {
+		template := lex.templates[len(lex.templates)-1]
+		lex.err_at(template.start, template.start+1, "unterminated embedded expression in string")
+	} else {
+		lex.err_at(start, lex.pos, "unterminated "+kind)
+	}
+
+	lex.forceExit = true
+}
+
+type source_line struct {
+	start int
+	end   int
+}
+
+// Splits source[start:end] into lines, without their line breaks
+func (lex *Lexer) split_lines(start int, end int) []source_line {
+	lines := make([]source_line, 0)
+
+	for {
+		newline := strings.IndexByte(lex.source[start:end], '\n')
+		if newline < 0 {
+			return append(lines, source_line{start, end})
+		}
+
+		line_end := start + newline
+		if line_end > start && lex.source[line_end-1] == '\r' {
+			line_end--
+		}
+
+		lines = append(lines, source_line{start, line_end})
+		start += newline + 1
+	}
+}
+
+func (lex *Lexer) indentation(line source_line) int {
+	width := 0
+	for line.start+width < line.end && (lex.source[line.start+width] == ' ' || lex.source[line.start+width] == '\t') {
+		width++
+	}
+
+	return width
+}
+
+func (lex *Lexer) is_blank(line source_line) bool {
+	return lex.indentation(line) == line.end-line.start
+}
+
+// Multi-line strings drop the line break after the opening quotes, the
+// indentation of the closing quotes and the indentation common to all
+// non-blank lines. Escapes are decoded afterwards, a backslash at the end of
+// a line escapes its line break, which joins it with the next line.
+func (lex *Lexer) dedent(start int, end int) string {
+	lines := lex.split_lines(start, end)
+
+	if len(lines) == 1 {
+		return lex.decode(start, end)
+	}
+
+	if lex.is_blank(lines[0]) {
+		lines = lines[1:]
+	}
+
+	if len(lines) > 0 && lex.is_blank(lines[len(lines)-1]) {
+		lines = lines[:len(lines)-1]
+	}
+
+	indent := -1
+	for _, line := range lines {
+		if !lex.is_blank(line) && (indent < 0 || lex.indentation(line) < indent) {
+			indent = lex.indentation(line)
+		}
+	}
+
+	var builder strings.Builder
+	for index, line := range lines {
+		escaped := lex.escapes_line_break(line)
+
+		if !lex.is_blank(line) && escaped {
+			builder.WriteString(lex.decode(line.start+indent, line.end-1))
+		} else if !lex.is_blank(line) {
+			builder.WriteString(lex.decode(line.start+indent, line.end))
+		}
+
+		if index < len(lines)-1 && !escaped {
+			builder.WriteByte('\n')
+		}
+	}
+
+	return builder.String()
+}
+
+// An odd number of backslashes at the end of a line escapes the line break
+func (lex *Lexer) escapes_line_break(line source_line) bool {
+	backslashes := 0
+	for line.end-backslashes > line.start && lex.source[line.end-backslashes-1] == '\\' {
+		backslashes++
+	}
+
+	return backslashes%2 == 1
+}
+
+// Decodes the escape sequences in source[start:end]
+func (lex *Lexer) decode(start int, end int) string {
+	raw := lex.source[start:end]
+
+	if strings.IndexByte(raw, '\\') < 0 {
+		return raw
+	}
+
+	var builder strings.Builder
+	builder.Grow(len(raw))
+
+	for i := start; i < end; {
+		if lex.source[i] != '\\' {
+			builder.WriteByte(lex.source[i])
+			i++
+			continue
+		}
+
+		i = lex.decode_escape(&builder, i, end)
+	}
+
+	return builder.String()
+}
+
+// Decodes the escape sequence starting at i and returns the offset after it
+func (lex *Lexer) decode_escape(builder *strings.Builder, i int, end int) int {
+	if i+1 >= end {
+		lex.err_at(i, i+1, "incomplete escape sequence")
+		return end
+	}
+
+	c := lex.source[i+1]
+
+	if decoded, exists := escape_lookup[c]; exists {
+		builder.WriteByte(decoded)
+		return i + 2
+	}
+
+	if c == 'u' {
+		return lex.decode_unicode_escape(builder, i, end)
+	}
+
+	_, size := utf8.DecodeRuneInString(lex.source[i+1 : end])
+	next := i + 1 + size
+	lex.err_at(i, next, fmt.Sprintf("invalid escape sequence %s", lex.source[i:next]))
+	builder.WriteString(lex.source[i:next])
+	return next
+}
+
+// Decodes \u{X} to \u{XXXXXX}
+func (lex *Lexer) decode_unicode_escape(builder *strings.Builder, i int, end int) int {
+	if i+2 >= end || lex.source[i+2] != '{' {
+		lex.err_at(i, i+2, `unicode escapes must be written as \u{XXXX}`)
+		return i + 2
+	}
+
+	close := strings.IndexByte(lex.source[i+3:end], '}')
+
+	if close < 0 {
+		lex.err_at(i, i+3, "unterminated unicode escape")
+		return i + 3
+	}
+
+	digits := lex.source[i+3 : i+3+close]
+	next := i + 3 + close + 1
+	value, err := strconv.ParseUint(digits, 16, 32)
+
+	if err != nil || len(digits) > 6 {
+		lex.err_at(i, next, fmt.Sprintf("invalid unicode escape %s", lex.source[i:next]))
+		return next
+	}
+
+	if !utf8.ValidRune(rune(value)) {
+		lex.err_at(i, next, fmt.Sprintf("unicode escape %s is not a valid code point", lex.source[i:next]))
+		return next
+	}
+
+	builder.WriteRune(rune(value))
+	return next
+}
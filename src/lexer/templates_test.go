@@ -0,0 +1,77 @@
+package lexer
+
+import (
+	"fmt"
+	"testing"
+)
+
+func literals(tokens []Token, kinds ...TokenKind) []string {
+	result := []string{}
+	for _, token := range tokens {
+		for _, kind := range kinds {
+			if token.Kind == kind {
+				result = append(result, token.Literal)
+			}
+		}
+	}
+	return result
+}
+
+func TestTemplates(t *testing.T) {
+	tokens := expect_kinds(t, `"a {x} b {y + 1} c"`, TEMPLATE_HEAD, IDENTIFIER, TEMPLATE_MIDDLE, IDENTIFIER, PLUS, NUMBER, TEMPLATE_TAIL)
+
+	if got := literals(tokens, TEMPLATE_HEAD, TEMPLATE_MIDDLE, TEMPLATE_TAIL); fmt.Sprintf("%q", got) != `["a " " b " " c"]` {
+		t.Errorf("unexpected literal parts %q", got)
+	}
+
+	expect_kinds(t, `"{x}"`, TEMPLATE_HEAD, IDENTIFIER, TEMPLATE_TAIL)
+}
+
+func TestCurliesInsideEmbeddedExpressions(t *testing.T) {
+	expect_kinds(t, `"x { {a: 1}.a } y"`, TEMPLATE_HEAD, OPEN_CURLY, IDENTIFIER, COLON, NUMBER, CLOSE_CURLY, DOT, IDENTIFIER, TEMPLATE_TAIL)
+	expect_kinds(t, `"{ fn () -> int { return 1; }() }"`, TEMPLATE_HEAD, FN, OPEN_PAREN, CLOSE_PAREN, R_ARROW, IDENTIFIER, OPEN_CURLY, RETURN, NUMBER, SEMI_COLON, CLOSE_CURLY, OPEN_PAREN, CLOSE_PAREN, TEMPLATE_TAIL)
+}
+
+func TestStringsInsideEmbeddedExpressions(t *testing.T) {
+	tokens := expect_kinds(t, `"a { "b {2} c" + "}" } d"`, TEMPLATE_HEAD, TEMPLATE_HEAD, NUMBER, TEMPLATE_TAIL, PLUS, STRING, TEMPLATE_TAIL)
+
+	if got := literals(tokens, TEMPLATE_HEAD, TEMPLATE_TAIL, STRING); fmt.Sprintf("%q", got) != `["a " "b " " c" "}" " d"]` {
+		t.Errorf("unexpected literal parts %q", got)
+	}
+}
+
+func TestEscapedCurliesArentEmbedded(t *testing.T) {
+	tokens := expect_kinds(t, `"\{lit\}"`, STRING)
+
+	if tokens[0].Literal != "{lit}" {
+		t.Errorf("expected literal curlies, got %q", tokens[0].Literal)
+	}
+
+	tokens = expect_kinds(t, `"\u{41}{1}"`, TEMPLATE_HEAD, NUMBER, TEMPLATE_TAIL)
+
+	if tokens[0].Literal != "A" {
+		t.Errorf("expected the unicode escape to be decoded, got %q", tokens[0].Literal)
+	}
+}
+
+func TestUnterminatedTemplates(t *testing.T) {
+	tests := []struct {
+		source  string
+		message string
+		token   string
+	}{
+		// The quote after 1 starts a new string
+		{`"x {1"`, "unterminated embedded expression in string", "{"},
+		{`"x {1 + 2`, "unterminated embedded expression in string", "{"},
+		{`"x { {a: 1}.a "`, "unterminated embedded expression in string", "{"},
+		{`"x {1} y`, "unterminated string literal", `} y`},
+	}
+
+	for _, test := range tests {
+		_, errors := Tokenize(test.source)
+
+		if len(errors) != 1 || errors[0].Message != test.message || errors[0].TokenLiteral != test.token {
+			t.Errorf("%s: expected the error %q at %q, got %v", test.source, test.message, test.token, errors)
+		}
+	}
+}
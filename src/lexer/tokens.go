@@ -3,12 +3,18 @@ package lexer
 import (
 	"fmt"
 	"slices"
+	"sync"
 )
 
 type Token struct {
-	Kind     TokenKind
+	Kind TokenKind
+	// Byte offsets of the first byte and of the byte after the token
 	Position int
-	Literal  string
+	End      int
+	// 1-based line and column of the first byte
+	Line    int
+	Col     int
+	Literal string
 }
 
 func (token Token) Is(kinds ...TokenKind) bool {
@@ -21,7 +27,7 @@ func (token Token) IsReserved() bool {
 }
 
 func NewToken(kind TokenKind, literal string, position int) Token {
-	return Token{Kind: kind, Literal: literal, Position: position}
+	return Token{Kind: kind, Literal: literal, Position: position, End: position + len(literal)}
 }
 
 type TokenKind int
@@ -43,6 +49,10 @@ const (
 	// Datatypes
 	NUMBER
 	STRING
+	// Segments of a string with embedded expressions: "head{a}middle{b}tail"
+	TEMPLATE_HEAD
+	TEMPLATE_MIDDLE
+	TEMPLATE_TAIL
 
 	// Operators
 	ASSIGNMENT
@@ -77,6 +87,7 @@ const (
 
 	R_ARROW
 	L_ARROW
+	FAT_ARROW
 
 	DOT
 	SEMI_COLON
@@ -98,7 +109,9 @@ const (
 	IF
 	ELSE
 	FOR
+	IN
 	WHILE
+	SWITCH
 	// Values
 	TRUE
 	FALSE
@@ -124,7 +137,9 @@ var reserved_lookup = map[string]TokenKind{
 	"if":        IF,
 	"else":      ELSE,
 	"for":       FOR,
+	"in":        IN,
 	"while":     WHILE,
+	"switch":    SWITCH,
 	"true":      TRUE,
 	"false":     FALSE,
 	"interface": INTERFACE,
@@ -136,45 +151,89 @@ var reserved_lookup = map[string]TokenKind{
 	"break":     BREAK,
 }
 
+var symbol_lookup = map[string]TokenKind{
+	"[":   OPEN_BRACKET,
+	"]":   CLOSE_BRACKET,
+	"{":   OPEN_CURLY,
+	"}":   CLOSE_CURLY,
+	"(":   OPEN_PAREN,
+	")":   CLOSE_PAREN,
+	"==":  EQUALS,
+	"!=":  NOT_EQUALS,
+	"=":   ASSIGNMENT,
+	"!":   NOT,
+	"<-":  L_ARROW,
+	"->":  R_ARROW,
+	"=>":  FAT_ARROW,
+	"<=":  LESS_EQUALS,
+	"<":   LESS,
+	">=":  GREATER_EQUALS,
+	">":   GREATER,
+	"||":  OR,
+	"&&":  AND,
+	"...": SPREAD,
+	".":   DOT,
+	";":   SEMI_COLON,
+	":":   COLON,
+	"?":   QUESTION,
+	",":   COMMA,
+	"++":  PLUS_PLUS,
+	"--":  MINUS_MINUS,
+	"+=":  PLUS_EQUALS,
+	"-=":  MINUS_EQUALS,
+	"+":   PLUS,
+	"-":   MINUS,
+	"/":   SLASH,
+	"*":   STAR,
+	"%":   PERCENT,
+	"&":   AMPERSAND,
+}
+
+const max_symbol_length = 3
+
 var token_string_lookup = map[TokenKind]string{
-	EOF:            "eof",
-	IDENTIFIER:     "identifier",
-	NUMBER:         "number",
-	STRING:         "string",
-	ASSIGNMENT:     "assignment",
-	PLUS_PLUS:      "plus_plus",
-	MINUS_MINUS:    "minus_minus",
-	PLUS_EQUALS:    "plus_equals",
-	MINUS_EQUALS:   "minus_equals",
-	PLUS:           "plus",
-	MINUS:          "dash",
-	SLASH:          "slash",
-	STAR:           "star",
-	PERCENT:        "percent",
-	OR:             "or",
-	AND:            "and",
-	EQUALS:         "equals",
-	NOT_EQUALS:     "not_equals",
-	NOT:            "not",
-	LESS:           "less",
-	LESS_EQUALS:    "less_equals",
-	GREATER:        "greater",
-	GREATER_EQUALS: "greater_equals",
-	OPEN_BRACKET:   "open_bracket",
-	CLOSE_BRACKET:  "close_bracket",
-	OPEN_CURLY:     "open_curly",
-	CLOSE_CURLY:    "close_curly",
-	OPEN_PAREN:     "open_paren",
-	CLOSE_PAREN:    "close_paren",
-	R_ARROW:        "right_arrow",
-	L_ARROW:        "left_arrow",
-	DOT:            "dot",
-	SEMI_COLON:     "semi_colon",
-	COLON:          "colon",
-	QUESTION:       "question",
-	COMMA:          "comma",
-	AMPERSAND:      "ampersand",
-	SPREAD:         "spread",
+	EOF:             "eof",
+	IDENTIFIER:      "identifier",
+	NUMBER:          "number",
+	STRING:          "string",
+	TEMPLATE_HEAD:   "template_head",
+	TEMPLATE_MIDDLE: "template_middle",
+	TEMPLATE_TAIL:   "template_tail",
+	ASSIGNMENT:      "assignment",
+	PLUS_PLUS:       "plus_plus",
+	MINUS_MINUS:     "minus_minus",
+	PLUS_EQUALS:     "plus_equals",
+	MINUS_EQUALS:    "minus_equals",
+	PLUS:            "plus",
+	MINUS:           "dash",
+	SLASH:           "slash",
+	STAR:            "star",
+	PERCENT:         "percent",
+	OR:              "or",
+	AND:             "and",
+	EQUALS:          "equals",
+	NOT_EQUALS:      "not_equals",
+	NOT:             "not",
+	LESS:            "less",
+	LESS_EQUALS:     "less_equals",
+	GREATER:         "greater",
+	GREATER_EQUALS:  "greater_equals",
+	OPEN_BRACKET:    "open_bracket",
+	CLOSE_BRACKET:   "close_bracket",
+	OPEN_CURLY:      "open_curly",
+	CLOSE_CURLY:     "close_curly",
+	OPEN_PAREN:      "open_paren",
+	CLOSE_PAREN:     "close_paren",
+	R_ARROW:         "right_arrow",
+	L_ARROW:         "left_arrow",
+	FAT_ARROW:       "fat_arrow",
+	DOT:             "dot",
+	SEMI_COLON:      "semi_colon",
+	COLON:           "colon",
+	QUESTION:        "question",
+	COMMA:           "comma",
+	AMPERSAND:       "ampersand",
+	SPREAD:          "spread",
 }
 
 var Assignment_operation_lu = map[TokenKind]TokenKind{
@@ -184,7 +243,13 @@ var Assignment_operation_lu = map[TokenKind]TokenKind{
 	MINUS_MINUS:  MINUS,
 }
 
+var token_lookup_once sync.Once
+
 func InitTokenLookup() {
+	token_lookup_once.Do(init_token_lookup)
+}
+
+func init_token_lookup() {
 	for value, kind := range reserved_lookup {
 		_, exists := token_string_lookup[kind]
 		if exists {
@@ -0,0 +1,79 @@
+package lib
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// LineTable maps byte offsets of a source to lines and columns. The line
+// starts are computed once, so every lookup is a binary search. Lines end
+// at "\n", so "\r\n" line endings need no special handling.
+type LineTable struct {
+	source string
+	starts []int
+}
+
+func NewLineTable(source string) LineTable {
+	starts := []int{0}
+
+	for index := 0; index < len(source); index++ {
+		if source[index] == '\n' {
+			starts = append(starts, index+1)
+		}
+	}
+
+	return LineTable{source: source, starts: starts}
+}
+
+func (table LineTable) line_of(offset int) int {
+	return sort.Search(len(table.starts), func(i int) bool { return table.starts[i] > offset })
+}
+
+// Locate returns the 1-based line and column of a byte offset. Columns
+// count runes, not bytes.
+func (table LineTable) Locate(offset int) (line int, col int) {
+	line = table.line_of(offset)
+	return line, table.runes(table.starts[line-1], offset) + 1
+}
+
+// Number of runes between two offsets, offsets past the source count as one rune per byte
+func (table LineTable) runes(start int, end int) int {
+	if end <= len(table.source) {
+		return utf8.RuneCountInString(table.source[start:end])
+	}
+
+	return utf8.RuneCountInString(table.source[start:]) + end - len(table.source)
+}
+
+// LineStart returns the offset of the first byte of a 1-based line
+func (table LineTable) LineStart(line int) int {
+	return table.starts[line-1]
+}
+
+func (table LineTable) LineCount() int {
+	return len(table.starts)
+}
+
+// LineCursor locates offsets that mostly increase, like the ones of tokens,
+// without counting the runes of a line again for every lookup
+type LineCursor struct {
+	table  LineTable
+	offset int
+	line   int
+	col    int
+}
+
+func (table LineTable) Cursor() *LineCursor {
+	return &LineCursor{table: table, line: 1, col: 1}
+}
+
+func (cursor *LineCursor) Locate(offset int) (line int, col int) {
+	if offset < cursor.offset || cursor.table.line_of(offset) != cursor.line {
+		cursor.line, cursor.col = cursor.table.Locate(offset)
+	} else {
+		cursor.col += cursor.table.runes(cursor.offset, offset)
+	}
+
+	cursor.offset = offset
+	return cursor.line, cursor.col
+}
@@ -0,0 +1,42 @@
+package lib
+
+import "testing"
+
+func TestLocate(t *testing.T) {
+	table := NewLineTable("ab\ncä\r\nd")
+	tests := []struct{ offset, line, col int }{
+		{0, 1, 1},
+		{2, 1, 3},
+		{3, 2, 1},
+		// ä is two bytes but one column
+		{6, 2, 3},
+		{8, 3, 1},
+		// Offsets past the source count one column per byte
+		{10, 3, 3},
+	}
+
+	for _, test := range tests {
+		if line, col := table.Locate(test.offset); line != test.line || col != test.col {
+			t.Errorf("offset %d: expected %d:%d, got %d:%d", test.offset, test.line, test.col, line, col)
+		}
+	}
+
+	if table.LineCount() != 3 || table.LineStart(3) != 8 {
+		t.Errorf("expected 3 lines with the last starting at 8, got %d starting at %d", table.LineCount(), table.LineStart(3))
+	}
+}
+
+func TestCursorMatchesTheTable(t *testing.T) {
+	source := "let ä = 1;\r\n\tfoo(ä);\n\nx"
+	table := NewLineTable(source)
+	cursor := table.Cursor()
+	// Mostly increasing, with a step back like a lexer that rewinds
+	offsets := []int{0, 4, 6, 8, 12, 13, 17, 3, 18, 22, 23, 24}
+
+	for _, offset := range offsets {
+		line, col := table.Locate(offset)
+		if cursor_line, cursor_col := cursor.Locate(offset); cursor_line != line || cursor_col != col {
+			t.Errorf("offset %d: expected %d:%d, got %d:%d", offset, line, col, cursor_line, cursor_col)
+		}
+	}
+}
@@ -1,48 +1,48 @@
 package main
 
 import (
+	"fmt"
 	"os"
-
-	"github.com/lucaengelhard/lang/src/ast"
-	"github.com/lucaengelhard/lang/src/errorhandling"
-	"github.com/lucaengelhard/lang/src/lexer"
-	"github.com/lucaengelhard/lang/src/parser"
-	"github.com/lucaengelhard/lang/src/typechecker"
+	"sort"
 )
 
 func main() {
-	// Args
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
 
-	// Reading file
-	bytes, _ := os.ReadFile(os.Args[1])
-	source := string(bytes)
+	name := os.Args[1]
 
-	// Create errors
-	errors := make([]errorhandling.Error, 0)
+	if name == "-h" || name == "--help" || name == "help" {
+		usage()
+		return
+	}
 
-	// Tokenizing
-	tokens, lexer_errors := lexer.Tokenize(source)
-	errors = append(errors, lexer_errors...)
+	cmd, exists := commands[name]
 
-	// AST-Building
-	var abstract_syntax_tree ast.Stmt
-	var parser_errors []errorhandling.Error
-	if len(errors) == 0 {
-		abstract_syntax_tree, parser_errors = parser.Parse(tokens)
-		errors = append(errors, parser_errors...)
+	if !exists {
+		fmt.Fprintf(os.Stderr, "lang: unknown command %q\n\n", name)
+		usage()
+		os.Exit(2)
 	}
 
-	// Typechecking and updating of ast
-	if len(errors) == 0 {
-		type_errors := typechecker.Init(abstract_syntax_tree)
-		errors = append(errors, type_errors...)
+	os.Exit(cmd.run(os.Args[2:]))
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: lang <command> [flags] <file>")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
 	}
+	sort.Strings(names)
 
-	// Interpretation / Compilation
-	/* if len(errors) == 0 {
-		interpreter.Init(abstract_syntax_tree)
-	} */
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", name, commands[name].description)
+	}
 
-	// Error handling
-	errorhandling.PrintErrors(source, errors)
+	fmt.Fprintln(os.Stderr, "\nRun 'lang <command> -h' for the flags of a command.")
 }
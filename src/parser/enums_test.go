@@ -0,0 +1,46 @@
+package parser_test
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/lucaengelhard/lang/src/ast"
+)
+
+func TestEnumValues(t *testing.T) {
+	tree, errors := parse(t, `enum E { A, B = 5, C, D = "x", F, G = -2, H }`)
+
+	if len(errors) > 0 {
+		t.Fatalf("unexpected error: %s", errors[0].Message)
+	}
+
+	expected := []string{"A 0", "B 5", "C 6", `D "x"`, "F 4", "G -2", "H -1"}
+	elements := tree.Body[0].(ast.EnumStmt).Elements
+
+	if len(elements) != len(expected) {
+		t.Fatalf("expected %d members, got %d", len(expected), len(elements))
+	}
+
+	for index, element := range elements {
+		var value string
+		switch literal := element.Value.(type) {
+		case ast.IntExpr:
+			value = element.Name + " " + fmt.Sprint(literal.Value)
+		case ast.StringExpr:
+			value = element.Name + " " + strconv.Quote(literal.Value)
+		}
+
+		if value != expected[index] {
+			t.Errorf("expected member %s, got %s", expected[index], value)
+		}
+	}
+}
+
+func TestEnumValueErrors(t *testing.T) {
+	expect_errors(t, `enum E { A = 1.5, B }`, [2]string{"Enum values must be int or string literals", "1.5"})
+	expect_errors(t, `enum E { A = -2.5 }`, [2]string{"Enum values must be int or string literals", "-2.5"})
+	expect_errors(t, `enum E { A = foo }`, [2]string{"Enum values must be int or string literals", "foo"})
+	expect_errors(t, `enum E { A = 1, B = 1 }`, [2]string{"Value of B is already used by A", "B = 1"})
+	expect_errors(t, `enum E { A, A }`, [2]string{"Member A already exists on enum", "A"})
+}
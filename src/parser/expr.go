@@ -3,20 +3,32 @@ package parser
 import (
 	"fmt"
 	"reflect"
-	"strconv"
+	"slices"
 
 	"github.com/lucaengelhard/lang/src/ast"
 	"github.com/lucaengelhard/lang/src/lexer"
 )
 
-func parse_expr(p *parser, bp binding_power) ast.Expr {
+var expr_terminators = []lexer.TokenKind{
+	lexer.SEMI_COLON, lexer.COMMA, lexer.CLOSE_PAREN, lexer.CLOSE_BRACKET, lexer.CLOSE_CURLY, lexer.EOF,
+	lexer.TEMPLATE_MIDDLE, lexer.TEMPLATE_TAIL,
+}
+
+func parse_expr(p *Parser, bp binding_power) ast.Expr {
 	token := p.currentToken()
 	tokenKind := token.Kind
 	nud_fn, exists := nud_lu[tokenKind]
 
 	if !exists {
-		p.err(fmt.Sprintf("Unexpected token (nud) near: %s (%s)\n", tokenKind.ToString(), token.Literal))
-		return nil
+		message := fmt.Sprintf("Unexpected token (nud) near: %s (%s)\n", tokenKind.ToString(), token.Literal)
+
+		// A missing operand in front of a closing token doesn't derail the surrounding expression
+		if slices.Contains(expr_terminators, tokenKind) {
+			p.err(message)
+			return ast.BadExpr{Position: p.curentTokenPosition()}
+		}
+
+		p.fail(message)
 	}
 
 	left := nud_fn(p)
@@ -25,9 +37,12 @@ func parse_expr(p *parser, bp binding_power) ast.Expr {
 		tokenKind := p.currentTokenKind()
 		led_fn, exists := led_lu[tokenKind]
 
+		if tokenKind == lexer.OPEN_CURLY && p.no_struct_literal {
+			break
+		}
+
 		if !exists {
-			p.err(fmt.Sprintf("Unexpected token (led) near: %s (%s)\n", tokenKind.ToString(), token.Literal))
-			return nil
+			p.fail(fmt.Sprintf("Unexpected token (led) near: %s (%s)\n", tokenKind.ToString(), token.Literal))
 		}
 
 		left = led_fn(p, left, bp_lu[p.currentTokenKind()])
@@ -36,7 +51,7 @@ func parse_expr(p *parser, bp binding_power) ast.Expr {
 	return left
 }
 
-func parse_boolean_expr(p *parser) ast.Expr {
+func parse_boolean_expr(p *Parser) ast.Expr {
 	pos := p.curentTokenPosition()
 	switch p.currentTokenKind() {
 	case lexer.TRUE:
@@ -47,47 +62,77 @@ func parse_boolean_expr(p *parser) ast.Expr {
 		return ast.BoolExpr{Value: false, Position: pos}
 	default:
 		p.err(fmt.Sprintf("Cannot create boolean expression from %s\n", p.currentTokenKind().ToString()))
-		return ast.UnknowPrimary{}
+		return ast.BadExpr{Position: pos}
 	}
 }
 
-func parse_number_expr(p *parser) ast.Expr {
+func parse_number_expr(p *Parser) ast.Expr {
 	pos := p.curentTokenPosition()
 	val := p.advance().Literal
-	if i, err := strconv.ParseInt(val, 10, 64); err == nil {
-		return ast.IntExpr{
-			Value:    i,
+
+	// Invalid literals were reported by the lexer
+	if lexer.IsFloatLiteral(val) {
+		number, _ := lexer.ParseFloat(val)
+		return ast.FloatExpr{
+			Value:    number,
 			Position: pos,
 		}
 	}
 
-	number, _ := strconv.ParseFloat(val, 64)
-	return ast.FloatExpr{
-		Value:    number,
+	i, _ := lexer.ParseInt(val)
+	return ast.IntExpr{
+		Value:    i,
 		Position: pos,
 	}
 }
 
-func parse_string_expr(p *parser) ast.Expr {
+func parse_string_expr(p *Parser) ast.Expr {
 	pos := p.curentTokenPosition()
 	literal := p.advance().Literal
 	return ast.StringExpr{Value: literal, Position: pos}
 }
 
-func parse_symbol_expr(p *parser) ast.Expr {
+func parse_string_template_expr(p *Parser) ast.Expr {
+	pos := p.curentTokenPosition()
+	parts := []string{p.advance().Literal}
+	expressions := []ast.Expr{}
+
+	for {
+		expressions = append(expressions, parse_expr(p, default_bp))
+
+		segment := p.currentToken()
+		if segment.Kind != lexer.TEMPLATE_MIDDLE && segment.Kind != lexer.TEMPLATE_TAIL {
+			p.fail(fmt.Sprintf("Expected end of embedded expression but recieved %s instead", segment.Kind.ToString()))
+		}
+
+		p.advance()
+		parts = append(parts, segment.Literal)
+
+		if segment.Kind == lexer.TEMPLATE_TAIL {
+			break
+		}
+	}
+
+	return ast.StringTemplateExpr{
+		Parts:       parts,
+		Expressions: expressions,
+		Position:    p.spanFrom(pos),
+	}
+}
+
+func parse_symbol_expr(p *Parser) ast.Expr {
+	pos := p.curentTokenPosition()
 	var isReference = false
 	if p.currentTokenKind() == lexer.AMPERSAND {
 		isReference = true
 		p.advance()
 	}
 
-	pos := p.curentTokenPosition()
-	return ast.SymbolExpr{Value: p.advance().Literal, Position: pos, IsReference: isReference}
+	value := p.advance().Literal
+	return ast.SymbolExpr{Value: value, Position: p.spanFrom(pos), IsReference: isReference}
 }
 
-func parse_binary_expr(p *parser, left ast.Expr, bp binding_power) ast.Expr {
-	pos := p.curentTokenPosition()
-
+func parse_binary_expr(p *Parser, left ast.Expr, bp binding_power) ast.Expr {
 	operator := p.advance()
 	right := parse_expr(p, bp)
 
@@ -95,12 +140,11 @@ func parse_binary_expr(p *parser, left ast.Expr, bp binding_power) ast.Expr {
 		Left:     left,
 		Operator: operator,
 		Right:    right,
-		Position: pos,
+		Position: p.spanFrom(left.Pos()),
 	}
 }
 
-func parse_assignment_expr(p *parser, left ast.Expr, bp binding_power) ast.Expr {
-	pos := p.curentTokenPosition()
+func parse_assignment_expr(p *Parser, left ast.Expr, bp binding_power) ast.Expr {
 	operator := p.advance()
 	rightExpr := parse_expr(p, bp)
 
@@ -108,11 +152,11 @@ func parse_assignment_expr(p *parser, left ast.Expr, bp binding_power) ast.Expr
 		Operator: operator,
 		Right:    rightExpr,
 		Assignee: left,
-		Position: pos,
+		Position: p.spanFrom(left.Pos()),
 	}
 }
 
-func parser_prefix_expr(p *parser) ast.Expr {
+func parser_prefix_expr(p *Parser) ast.Expr {
 	pos := p.curentTokenPosition()
 	operator := p.advance()
 	rightExpr := parse_expr(p, default_bp)
@@ -120,23 +164,64 @@ func parser_prefix_expr(p *parser) ast.Expr {
 	return ast.PrefixExpr{
 		Operator: operator,
 		Right:    rightExpr,
-		Position: pos,
+		Position: p.spanFrom(pos),
 	}
 }
 
-func parse_postfix_expr(p *parser, left ast.Expr, bp binding_power) ast.Expr {
-	pos := p.curentTokenPosition()
+func parse_postfix_expr(p *Parser, left ast.Expr, bp binding_power) ast.Expr {
+	operatorPos := p.curentTokenPosition()
 	operator := p.advance()
 
 	return ast.AssignmentExpr{
 		Assignee: left,
 		Operator: operator,
-		Right:    ast.IntExpr{Value: 1},
-		Position: pos,
+		Right:    ast.IntExpr{Value: 1, Position: operatorPos},
+		Position: p.spanFrom(left.Pos()),
 	}
 }
 
-func parse_grouping_expr(p *parser) ast.Expr {
+func parse_if_expr(p *Parser) ast.Expr {
+	pos := p.curentTokenPosition()
+
+	p.expect(lexer.IF)
+	p.expect(lexer.OPEN_PAREN)
+	cond := parse_expr(p, assignment)
+	p.expect(lexer.CLOSE_PAREN)
+
+	true_expr := parse_expr(p, assignment)
+	p.expectError(lexer.ELSE, "Expected else, if expressions need a value for both branches")
+	false_expr := parse_expr(p, assignment)
+
+	return ast.IfExpr{
+		Condition: cond,
+		True:      true_expr,
+		False:     false_expr,
+		Position:  p.spanFrom(pos),
+	}
+}
+
+// Parses an expression that is directly followed by a block: switch x { ... }
+func parse_expr_before_block(p *Parser, bp binding_power) ast.Expr {
+	defer func(previous bool) { p.no_struct_literal = previous }(p.no_struct_literal)
+	p.no_struct_literal = true
+	return parse_expr(p, bp)
+}
+
+func parse_switch_expr(p *Parser) ast.Expr {
+	pos := p.curentTokenPosition()
+	subject, arms := parse_switch(p, false)
+
+	return ast.SwitchExpr{
+		Subject:  subject,
+		Arms:     arms,
+		Position: p.spanFrom(pos),
+	}
+}
+
+func parse_grouping_expr(p *Parser) ast.Expr {
+	defer func(previous bool) { p.no_struct_literal = previous }(p.no_struct_literal)
+	p.no_struct_literal = false
+
 	p.advance()
 	expr := parse_expr(p, default_bp)
 	p.expect(lexer.CLOSE_PAREN)
@@ -144,9 +229,7 @@ func parse_grouping_expr(p *parser) ast.Expr {
 	return expr
 }
 
-func parse_struct_instantiation_expr(p *parser, left ast.Expr, bp binding_power) ast.Expr {
-	pos := p.curentTokenPosition()
-
+func parse_struct_instantiation_expr(p *Parser, left ast.Expr, bp binding_power) ast.Expr {
 	symbol, ok := left.(ast.SymbolExpr)
 
 	if !ok {
@@ -176,11 +259,49 @@ func parse_struct_instantiation_expr(p *parser, left ast.Expr, bp binding_power)
 	return ast.StructInstantiationExpr{
 		StructIdentifier: structIdentifier,
 		Properties:       properties,
-		Position:         pos,
+		Position:         p.spanFrom(left.Pos()),
 	}
 }
 
-func parse_array_instantiation_expr(p *parser) ast.Expr {
+func parse_record_instantiation_expr(p *Parser) ast.Expr {
+	defer func(previous bool) { p.no_struct_literal = previous }(p.no_struct_literal)
+	p.no_struct_literal = false
+
+	pos := p.curentTokenPosition()
+	var properties = []ast.RecordProperty{}
+
+	p.expect(lexer.OPEN_CURLY)
+
+	for p.hasTokens() && p.currentTokenKind() != lexer.CLOSE_CURLY {
+		property_pos := p.curentTokenPosition()
+		name := p.expect(lexer.IDENTIFIER).Literal
+		p.expect(lexer.COLON)
+		value := parse_expr(p, logical)
+
+		if slices.ContainsFunc(properties, func(property ast.RecordProperty) bool { return property.Name == name }) {
+			p.err(fmt.Sprintf("Property %s already exists on record", name))
+		}
+
+		properties = append(properties, ast.RecordProperty{
+			Name:     name,
+			Value:    value,
+			Position: p.spanFrom(property_pos),
+		})
+
+		if p.currentTokenKind() != lexer.CLOSE_CURLY {
+			p.expect(lexer.COMMA)
+		}
+	}
+
+	p.expect(lexer.CLOSE_CURLY)
+
+	return ast.RecordInstantiationExpr{
+		Properties: properties,
+		Position:   p.spanFrom(pos),
+	}
+}
+
+func parse_array_instantiation_expr(p *Parser) ast.Expr {
 	pos := p.curentTokenPosition()
 	p.expect(lexer.OPEN_BRACKET)
 	var elements = []ast.Expr{}
@@ -195,18 +316,18 @@ func parse_array_instantiation_expr(p *parser) ast.Expr {
 
 	return ast.ArrayInstantiationExpr{
 		Elements: elements,
-		Position: pos,
+		Position: p.spanFrom(pos),
 	}
 }
 
-func parse_fn_call_expr(p *parser, left ast.Expr, bp binding_power) ast.Expr {
-	pos := p.curentTokenPosition()
+func parse_fn_call_expr(p *Parser, left ast.Expr, bp binding_power) ast.Expr {
 	var arguments = []ast.FnCallArg{}
 
 	p.expect(lexer.OPEN_PAREN)
 
 	for p.hasTokens() && p.currentTokenKind() != lexer.CLOSE_PAREN {
 		var argumentIdentifier string
+		argPos := p.curentTokenPosition()
 
 		if p.peekNextKind() == lexer.COLON {
 			argumentIdentifier = p.expect(lexer.IDENTIFIER).Literal
@@ -218,6 +339,7 @@ func parse_fn_call_expr(p *parser, left ast.Expr, bp binding_power) ast.Expr {
 		arguments = append(arguments, ast.FnCallArg{
 			Identifier: argumentIdentifier,
 			Value:      expr,
+			Position:   p.spanFrom(argPos),
 		})
 
 		if p.currentTokenKind() != lexer.CLOSE_PAREN {
@@ -230,15 +352,20 @@ func parse_fn_call_expr(p *parser, left ast.Expr, bp binding_power) ast.Expr {
 	return ast.FnCallExpr{
 		Caller:    left,
 		Arguments: arguments,
-		Position:  pos,
+		Binding:   &ast.CallBinding{},
+		Position:  p.spanFrom(left.Pos()),
 	}
 }
 
-func parse_fn_declare_anonymous_expr(p *parser) ast.Expr {
+func parse_fn_declare_anonymous_expr(p *Parser) ast.Expr {
+	pos := p.curentTokenPosition()
 	p.expect(lexer.FN)
-	return parse_fn_declare_expr(p)
+	fn := parse_fn_declare_expr(p).(ast.FnDeclareExpr)
+	fn.Position = p.spanFrom(pos)
+	return fn
 }
-func parse_fn_declare_expr(p *parser) ast.Expr {
+
+func parse_fn_declare_expr(p *Parser) ast.Expr {
 	pos := p.curentTokenPosition()
 	var arguments = map[string]ast.FnArg{}
 	var typeArg = ast.CreateUnsetType()
@@ -253,6 +380,7 @@ func parse_fn_declare_expr(p *parser) ast.Expr {
 
 	var arg_index = 0
 	for p.hasTokens() && p.currentTokenKind() != lexer.CLOSE_PAREN {
+		argPos := p.curentTokenPosition()
 		isMutable := p.currentTokenKind() == lexer.MUT
 		if isMutable {
 			p.advance()
@@ -278,6 +406,7 @@ func parse_fn_declare_expr(p *parser) ast.Expr {
 			ArgIndex:   arg_index,
 			IsMutable:  isMutable,
 			Type:       explicitType,
+			Position:   p.spanFrom(argPos),
 		}
 
 		if p.currentTokenKind() != lexer.CLOSE_PAREN {
@@ -306,40 +435,78 @@ func parse_fn_declare_expr(p *parser) ast.Expr {
 		Type:       typeArg,
 		ReturnType: returnType,
 		Body:       body,
-		Position:   pos,
+		Position:   p.spanFrom(pos),
 	}
 }
 
-func parse_chain_expr(p *parser, left ast.Expr, bp binding_power) ast.Expr {
-	pos := p.curentTokenPosition()
-
+func parse_chain_expr(p *Parser, left ast.Expr, bp binding_power) ast.Expr {
 	p.expect(lexer.DOT)
+	member := p.expectError(lexer.IDENTIFIER, "Expected the name of a member after .").Literal
 
 	return ast.ChainExpr{
 		Assignee: left,
-		Member:   parse_expr(p, default_bp),
-		Position: pos,
+		Member:   member,
+		Position: p.spanFrom(left.Pos()),
 	}
 }
 
-func parse_is_expr(p *parser, left ast.Expr, bp binding_power) ast.Expr {
-	pos := p.curentTokenPosition()
+// Parses a[i] and the slices a[start:end], a[start:] and a[:end]
+func parse_index_expr(p *Parser, left ast.Expr, bp binding_power) ast.Expr {
+	defer func(previous bool) { p.no_struct_literal = previous }(p.no_struct_literal)
+	p.no_struct_literal = false
+
+	p.expect(lexer.OPEN_BRACKET)
+	var start ast.Expr
+
+	if p.currentTokenKind() != lexer.COLON {
+		start = parse_expr(p, default_bp)
+	}
+
+	if p.currentTokenKind() != lexer.COLON {
+		p.expect(lexer.CLOSE_BRACKET)
+
+		return ast.IndexExpr{
+			Assignee: left,
+			Index:    start,
+			Position: p.spanFrom(left.Pos()),
+		}
+	}
+
+	p.expect(lexer.COLON)
+	var end ast.Expr
+
+	if p.currentTokenKind() != lexer.CLOSE_BRACKET {
+		end = parse_expr(p, default_bp)
+	}
+
+	p.expect(lexer.CLOSE_BRACKET)
+
+	return ast.SliceExpr{
+		Assignee: left,
+		Start:    start,
+		End:      end,
+		Position: p.spanFrom(left.Pos()),
+	}
+}
+
+func parse_is_expr(p *Parser, left ast.Expr, bp binding_power) ast.Expr {
 	p.expect(lexer.IS)
 	right := parse_type(p, bp)
 
 	return ast.IsTypeExpr{
 		Left:     left,
 		Right:    right,
-		Position: pos,
+		Position: p.spanFrom(left.Pos()),
 	}
 }
 
-func parse_deref_expr(p *parser) ast.Expr {
+func parse_deref_expr(p *Parser) ast.Expr {
 	pos := p.curentTokenPosition()
 	p.expect(lexer.STAR)
+	ref := parse_expr(p, primary)
 
 	return ast.DerefExpr{
-		Ref:      parse_expr(p, primary),
-		Position: pos,
+		Ref:      ref,
+		Position: p.spanFrom(pos),
 	}
 }
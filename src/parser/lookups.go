@@ -21,9 +21,9 @@ const (
 	primary
 )
 
-type stmt_handler func(p *parser) ast.Stmt
-type nud_handler func(p *parser) ast.Expr
-type led_handler func(p *parser, left ast.Expr, bp binding_power) ast.Expr
+type stmt_handler func(p *Parser) ast.Stmt
+type nud_handler func(p *Parser) ast.Expr
+type led_handler func(p *Parser, left ast.Expr, bp binding_power) ast.Expr
 
 type stmt_lookup map[lexer.TokenKind]stmt_handler
 type nud_lookup map[lexer.TokenKind]nud_handler
@@ -66,7 +66,8 @@ func createTokenLookups() {
 	led(lexer.SLASH, multiplicative, parse_binary_expr)
 	led(lexer.PERCENT, multiplicative, parse_binary_expr)
 
-	led(lexer.DOT, primary, parse_chain_expr)
+	led(lexer.DOT, member, parse_chain_expr)
+	led(lexer.OPEN_BRACKET, member, parse_index_expr)
 	nud(lexer.SPREAD, parser_prefix_expr)
 
 	led(lexer.ASSIGNMENT, assignment, parse_assignment_expr)
@@ -77,6 +78,7 @@ func createTokenLookups() {
 
 	nud(lexer.NUMBER, parse_number_expr)
 	nud(lexer.STRING, parse_string_expr)
+	nud(lexer.TEMPLATE_HEAD, parse_string_template_expr)
 	nud(lexer.IDENTIFIER, parse_symbol_expr)
 	nud(lexer.AMPERSAND, parse_symbol_expr)
 	nud(lexer.TRUE, parse_boolean_expr)
@@ -90,8 +92,12 @@ func createTokenLookups() {
 	nud(lexer.FN, parse_fn_declare_anonymous_expr)
 	nud(lexer.LESS, parse_fn_declare_anonymous_expr)
 	nud(lexer.OPEN_BRACKET, parse_array_instantiation_expr)
+	nud(lexer.OPEN_CURLY, parse_record_instantiation_expr)
+	nud(lexer.IF, parse_if_expr)
+	nud(lexer.SWITCH, parse_switch_expr)
 
 	stmt(lexer.LET, parse_declaration_stmt)
+	stmt(lexer.SWITCH, parse_switch_stmt)
 	stmt(lexer.STRUCT, parse_struct_stmt)
 	stmt(lexer.INTERFACE, parse_interface_stmt)
 	stmt(lexer.ENUM, parse_enum_stmt)
@@ -2,22 +2,37 @@ package parser
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/lucaengelhard/lang/src/ast"
 	"github.com/lucaengelhard/lang/src/errorhandling"
 	"github.com/lucaengelhard/lang/src/lexer"
 )
 
-type parser struct {
+// Parser holds the state of parsing a single token stream. Several parsers
+// can be used concurrently, a single parser must not.
+type Parser struct {
 	tokens []lexer.Token
 	index  int
 	errors []errorhandling.Error
+	// Set while parsing an expression that is followed by a block, where a
+	// curly can't start a struct instantiation
+	no_struct_literal bool
 }
 
-func createParser(tokens []lexer.Token) *parser {
-	createTokenLookups()
-	createTokenTypeLookups()
-	return &parser{
+var lookups_once sync.Once
+
+// The lookups are only written once and can be shared by all parsers afterwards
+func createLookups() {
+	lookups_once.Do(func() {
+		createTokenLookups()
+		createTokenTypeLookups()
+	})
+}
+
+func New(tokens []lexer.Token) *Parser {
+	createLookups()
+	return &Parser{
 		tokens: tokens,
 		index:  0,
 		errors: make([]errorhandling.Error, 0),
@@ -25,58 +40,175 @@ func createParser(tokens []lexer.Token) *parser {
 }
 
 func Parse(tokens []lexer.Token) (ast.Stmt, []errorhandling.Error) {
-	p := createParser(tokens)
-	body := parse_block_stmt(p)
-	return body, p.errors
+	return New(tokens).Parse()
+}
+
+func (p *Parser) Parse() (ast.Stmt, []errorhandling.Error) {
+	start_pos := p.curentTokenPosition()
+	body := make([]ast.Stmt, 0)
+
+	for p.hasTokens() {
+		body = append(body, parse_block_stmt(p).Body...)
+
+		// parse_block_stmt only stops early at a closing curly without an opening one
+		if p.currentTokenKind() == lexer.CLOSE_CURLY {
+			p.err("Unexpected token CLOSE_CURLY without matching OPEN_CURLY")
+			p.advance()
+		}
+	}
+
+	return ast.BlockStmt{
+		Body:     body,
+		Position: p.spanFrom(start_pos),
+	}, p.errors
 }
 
-func (p *parser) currentToken() lexer.Token {
-	return p.tokens[p.index]
+func (p *Parser) currentToken() lexer.Token {
+	return p.tokens[min(p.index, len(p.tokens)-1)]
 }
 
-func (p *parser) printCurrentToken() {
+func (p *Parser) printCurrentToken() {
 	fmt.Println(p.currentTokenKind().ToString())
 }
 
-func (p *parser) curentTokenPosition() ast.Position {
-	return ast.Position{
-		Start: p.currentToken().Position,
-		End:   p.currentToken().Position + len(p.currentToken().Literal),
+func (p *Parser) curentTokenPosition() ast.Position {
+	return ast.TokenPosition(p.currentToken())
+}
+
+func (p *Parser) previousToken() lexer.Token {
+	if p.index == 0 {
+		return p.currentToken()
 	}
+
+	return p.tokens[p.index-1]
+}
+
+// Returns the span from start to the end of the last consumed token
+func (p *Parser) spanFrom(start ast.Position) ast.Position {
+	return start.To(ast.TokenPosition(p.previousToken()))
 }
 
-func (p *parser) peekNext() lexer.Token {
-	return p.tokens[p.index+1]
+// Returns the token after the current one or the trailing EOF token
+func (p *Parser) peekNext() lexer.Token {
+	return p.tokens[min(p.index+1, len(p.tokens)-1)]
 }
 
-func (p *parser) peekNextKind() lexer.TokenKind {
+func (p *Parser) peekNextKind() lexer.TokenKind {
 	return p.peekNext().Kind
 }
 
-func (p *parser) advance() lexer.Token {
+func (p *Parser) advance() lexer.Token {
 	tk := p.currentToken()
-	p.index++
+	if p.index < len(p.tokens)-1 {
+		p.index++
+	}
 	return tk
 }
 
-func (p *parser) hasTokens() bool {
+func (p *Parser) hasTokens() bool {
 	return p.index < len(p.tokens) && p.currentTokenKind() != lexer.EOF
 }
 
-func (p *parser) currentTokenKind() lexer.TokenKind {
+func (p *Parser) currentTokenKind() lexer.TokenKind {
 	return p.currentToken().Kind
 }
 
-func (p *parser) err(msg string) {
+func (p *Parser) err(msg string) {
 	token := p.currentToken()
+	p.record(token.Position, token.End, token.Literal, msg)
+}
+
+// Records an error at the tokens consumed since the token at index start,
+// their literal is taken from the source
+func (p *Parser) errSince(start int, msg string) {
+	if start >= p.index {
+		p.err(msg)
+		return
+	}
+
+	p.record(p.tokens[start].Position, p.previousToken().End, "", msg)
+}
+
+func (p *Parser) record(position int, end int, literal string, msg string) {
+	// Only the first error at a token is useful, the rest follow from it
+	if len(p.errors) > 0 && p.errors[len(p.errors)-1].Position == position {
+		return
+	}
+
 	p.errors = append(p.errors, errorhandling.Error{
-		Message:      "Parser error -> " + msg,
-		Position:     token.Position,
-		TokenLiteral: token.Literal,
+		Message:      msg,
+		Phase:        errorhandling.PARSER,
+		Position:     position,
+		End:          end,
+		TokenLiteral: literal,
 	})
 }
 
-func (p *parser) expectError(expected lexer.TokenKind, msg any) lexer.Token {
+// Raised to abandon the statement that is being parsed, see parse_stmt
+type bailout struct{}
+
+// Records an error and abandons the current statement
+func (p *Parser) fail(msg string) {
+	p.err(msg)
+	panic(bailout{})
+}
+
+// Skips tokens until a point where parsing can resume after a syntax error:
+// past the next semicolon or balanced block, or before a closing curly or
+// statement keyword. Blocks the statement opened before the error are
+// skipped until they close. At least one token is skipped if none was
+// consumed since start.
+func (p *Parser) synchronize(start int) {
+	// Curlies the abandoned statement opened and didn't close yet
+	depth := 0
+	for _, token := range p.tokens[start:p.index] {
+		switch token.Kind {
+		case lexer.OPEN_CURLY:
+			depth++
+		case lexer.CLOSE_CURLY:
+			depth = max(depth-1, 0)
+		}
+	}
+
+	if p.index == start {
+		p.advance()
+	}
+
+	for p.hasTokens() {
+		kind := p.currentTokenKind()
+
+		switch kind {
+		case lexer.OPEN_CURLY:
+			depth++
+		case lexer.CLOSE_CURLY:
+			if depth == 0 {
+				return
+			}
+
+			depth--
+			if depth == 0 {
+				p.advance()
+				if p.currentTokenKind() == lexer.SEMI_COLON {
+					p.advance()
+				}
+				return
+			}
+		case lexer.SEMI_COLON:
+			if depth == 0 {
+				p.advance()
+				return
+			}
+		}
+
+		if _, is_stmt := stmt_lu[kind]; is_stmt && depth == 0 {
+			return
+		}
+
+		p.advance()
+	}
+}
+
+func (p *Parser) expectError(expected lexer.TokenKind, msg any) lexer.Token {
 	token := p.currentToken()
 	kind := token.Kind
 
@@ -84,17 +216,17 @@ func (p *parser) expectError(expected lexer.TokenKind, msg any) lexer.Token {
 		if msg == nil {
 			msg = fmt.Sprintf("Expected token %s but recieved %s instead", expected.ToString(), kind.ToString())
 		}
-		p.err(fmt.Sprintf("%v", msg))
+		p.fail(fmt.Sprintf("%v", msg))
 	}
 
 	return p.advance()
 }
 
-func (p *parser) expect(expected lexer.TokenKind) lexer.Token {
+func (p *Parser) expect(expected lexer.TokenKind) lexer.Token {
 	return p.expectError(expected, nil)
 }
 
-func (p *parser) nextIsKind(expected lexer.TokenKind) bool {
+func (p *Parser) nextIsKind(expected lexer.TokenKind) bool {
 	p.advance()
 	if p.currentTokenKind() != expected {
 		return false
@@ -0,0 +1,58 @@
+package parser_test
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/lucaengelhard/lang/src/ast"
+	"github.com/lucaengelhard/lang/src/errorhandling"
+	"github.com/lucaengelhard/lang/src/lexer"
+	"github.com/lucaengelhard/lang/src/parser"
+)
+
+func parse(t *testing.T, source string) (ast.BlockStmt, []errorhandling.Error) {
+	t.Helper()
+	tokens, errors := lexer.Tokenize(source)
+
+	if len(errors) > 0 {
+		t.Fatalf("unexpected lexer error in %q: %s", source, errors[0].Message)
+	}
+
+	tree, errors := parser.Parse(tokens)
+	return tree.(ast.BlockStmt), errors
+}
+
+// Expects parsing the source to report exactly the given errors in order,
+// each given as its message and the source it points at
+func expect_errors(t *testing.T, source string, expected ...[2]string) {
+	t.Helper()
+	_, errors := parse(t, source)
+	got := make([][2]string, 0, len(errors))
+
+	for _, err := range errors {
+		got = append(got, [2]string{strings.TrimSpace(err.Message), source[err.Position:max(err.End, err.Position)]})
+	}
+
+	if !slices.Equal(got, expected) {
+		t.Errorf("parsing %q: expected errors %q, got %q", source, expected, got)
+	}
+}
+
+// Run with -race to find state shared between parsers
+func TestConcurrentParsers(t *testing.T) {
+	var group sync.WaitGroup
+
+	for range 16 {
+		group.Go(func() {
+			tree, errors := parse(t, `let a = 1 + 2 * 3; fn f(x: int) -> int { return x; } f(a);`)
+
+			if len(errors) > 0 || len(tree.Body) != 3 {
+				t.Errorf("expected three statements without errors, got %d and %v", len(tree.Body), errors)
+			}
+		})
+	}
+
+	group.Wait()
+}
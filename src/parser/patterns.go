@@ -0,0 +1,124 @@
+package parser
+
+import (
+	"github.com/lucaengelhard/lang/src/ast"
+	"github.com/lucaengelhard/lang/src/lexer"
+)
+
+// Parses switch subject { arms }. Arms with block bodies are only allowed
+// in switch statements.
+func parse_switch(p *Parser, allow_blocks bool) (ast.Expr, []ast.SwitchArm) {
+	p.expect(lexer.SWITCH)
+	subject := parse_expr_before_block(p, default_bp)
+	p.expect(lexer.OPEN_CURLY)
+
+	arms := make([]ast.SwitchArm, 0)
+	for p.hasTokens() && p.currentTokenKind() != lexer.CLOSE_CURLY {
+		arm := parse_switch_arm(p, allow_blocks)
+		arms = append(arms, arm)
+
+		// The comma after a block is optional
+		_, is_block := arm.Body.(ast.BlockStmt)
+		if p.currentTokenKind() == lexer.COMMA || !is_block && p.currentTokenKind() != lexer.CLOSE_CURLY {
+			p.expect(lexer.COMMA)
+		}
+	}
+
+	p.expect(lexer.CLOSE_CURLY)
+	return subject, arms
+}
+
+func parse_switch_arm(p *Parser, allow_blocks bool) ast.SwitchArm {
+	pos := p.curentTokenPosition()
+	pattern := parse_pattern(p)
+
+	var guard ast.Expr
+	if p.currentTokenKind() == lexer.IF {
+		p.advance()
+		guard = parse_expr(p, assignment)
+	}
+
+	p.expect(lexer.FAT_ARROW)
+
+	var body ast.Stmt
+	// Arms of switch expressions starting with a curly are records
+	if p.currentTokenKind() == lexer.OPEN_CURLY && allow_blocks {
+		block_pos := p.curentTokenPosition()
+		p.advance()
+		block := parse_block_stmt(p)
+		p.expect(lexer.CLOSE_CURLY)
+		block.Position = p.spanFrom(block_pos)
+		body = block
+	} else {
+		expr := parse_expr(p, assignment)
+		body = ast.ExpressionStmt{Expression: expr, Position: expr.Pos()}
+	}
+
+	return ast.SwitchArm{
+		Pattern:  pattern,
+		Guard:    guard,
+		Body:     body,
+		Position: p.spanFrom(pos),
+	}
+}
+
+// Patterns are parsed as expressions, literals become value patterns, single
+// names name patterns and every other expression a condition
+func parse_pattern(p *Parser) ast.Pattern {
+	if p.currentTokenKind() == lexer.IDENTIFIER && p.peekNextKind() == lexer.OPEN_CURLY {
+		return parse_destructure_pattern(p)
+	}
+
+	expr := parse_expr_before_block(p, default_bp)
+
+	switch expr := expr.(type) {
+	case ast.IntExpr, ast.FloatExpr, ast.StringExpr, ast.BoolExpr, ast.ChainExpr:
+		return ast.ValuePattern{Value: expr, Position: expr.Pos()}
+	case ast.PrefixExpr:
+		if expr.Operator.Kind == lexer.MINUS {
+			return ast.ValuePattern{Value: expr, Position: expr.Pos()}
+		}
+	case ast.SymbolExpr:
+		if !expr.IsReference {
+			return ast.NamePattern{Name: expr.Value, Position: expr.Position}
+		}
+	}
+
+	return ast.ConditionPattern{Condition: expr, Position: expr.Pos()}
+}
+
+func parse_destructure_pattern(p *Parser) ast.Pattern {
+	pos := p.curentTokenPosition()
+	type_name := p.expect(lexer.IDENTIFIER).Literal
+	p.expect(lexer.OPEN_CURLY)
+
+	fields := make([]ast.FieldPattern, 0)
+	for p.hasTokens() && p.currentTokenKind() != lexer.CLOSE_CURLY {
+		field_pos := p.curentTokenPosition()
+		name := p.expect(lexer.IDENTIFIER).Literal
+
+		var pattern ast.Pattern = ast.NamePattern{Name: name, Position: field_pos}
+		if p.currentTokenKind() == lexer.COLON {
+			p.advance()
+			pattern = parse_pattern(p)
+		}
+
+		fields = append(fields, ast.FieldPattern{
+			Name:     name,
+			Pattern:  pattern,
+			Position: p.spanFrom(field_pos),
+		})
+
+		if p.currentTokenKind() != lexer.CLOSE_CURLY {
+			p.expect(lexer.COMMA)
+		}
+	}
+
+	p.expect(lexer.CLOSE_CURLY)
+
+	return ast.DestructurePattern{
+		Type:     type_name,
+		Fields:   fields,
+		Position: p.spanFrom(pos),
+	}
+}
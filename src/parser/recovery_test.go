@@ -0,0 +1,51 @@
+package parser_test
+
+import (
+	"fmt"
+	"testing"
+)
+
+func expect_statements(t *testing.T, source string, expected ...string) {
+	t.Helper()
+	tree, _ := parse(t, source)
+	got := make([]string, 0, len(tree.Body))
+
+	for _, stmt := range tree.Body {
+		got = append(got, fmt.Sprintf("%T", stmt))
+	}
+
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("parsing %q: expected statements %v, got %v", source, expected, got)
+	}
+}
+
+func TestEveryStatementReportsItsError(t *testing.T) {
+	expect_errors(t, `let a = ; let b = 2; let c = );`,
+		[2]string{"Unexpected token (nud) near: semi_colon (;)", ";"},
+		[2]string{"Unexpected token (nud) near: close_paren ())", ")"},
+	)
+	expect_errors(t, `fn f( { return 1; } let x = 1 +;`,
+		[2]string{"Expected token identifier but recieved open_curly instead", "{"},
+		[2]string{"Unexpected token (nud) near: semi_colon (;)", ";"},
+	)
+	expect_errors(t, "fn f() { let a = ; let b = 1 } let c = ;",
+		[2]string{"Unexpected token (nud) near: semi_colon (;)", ";"},
+		[2]string{"Expected token semi_colon but recieved close_curly instead", "}"},
+		[2]string{"Unexpected token (nud) near: semi_colon (;)", ";"},
+	)
+}
+
+func TestErrorsDontCascade(t *testing.T) {
+	expect_errors(t, "let a = 1\nlet b = 2;", [2]string{"Expected token semi_colon but recieved let instead", "let"})
+	expect_errors(t, `let a = [1, 2; let b = 3;`, [2]string{"Expected token comma but recieved semi_colon instead", ";"})
+	expect_errors(t, `struct S { a: int b: int; } let q = 1 1;`,
+		[2]string{"Type Led handler expected for token identifier", "b"},
+		[2]string{"Expected token semi_colon but recieved number instead", "1"},
+	)
+}
+
+func TestRecoveredStatementsAreKept(t *testing.T) {
+	expect_statements(t, `let a = ; let b = 2; b;`, "ast.DeclarationStmt", "ast.DeclarationStmt", "ast.ExpressionStmt")
+	expect_statements(t, `fn f( { return 1; } f(1);`, "ast.BadStmt", "ast.ExpressionStmt")
+	expect_statements(t, "let a = 1\nlet b = 2;", "ast.BadStmt", "ast.DeclarationStmt")
+}
@@ -0,0 +1,44 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/lucaengelhard/lang/src/ast"
+)
+
+// Nodes span from their first to their last token
+func TestSpans(t *testing.T) {
+	source := "let mut total: int = f(a, b: 2) + [1,\n  2][0];\nwhile (x < 3) { x += 1; }"
+	tree, errors := parse(t, source)
+
+	if len(errors) > 0 {
+		t.Fatalf("unexpected error: %s", errors[0].Message)
+	}
+
+	declaration := tree.Body[0].(ast.DeclarationStmt)
+	sum := declaration.AssignedValue.(ast.BinaryExpr)
+	call := sum.Left.(ast.FnCallExpr)
+	index := sum.Right.(ast.IndexExpr)
+
+	spans := []struct {
+		node     ast.Position
+		expected string
+	}{
+		{declaration.Position, "let mut total: int = f(a, b: 2) + [1,\n  2][0];"},
+		{sum.Position, "f(a, b: 2) + [1,\n  2][0]"},
+		{call.Position, "f(a, b: 2)"},
+		{call.Arguments[1].Position, "b: 2"},
+		{index.Position, "[1,\n  2][0]"},
+		{tree.Body[1].Pos(), "while (x < 3) { x += 1; }"},
+	}
+
+	for _, span := range spans {
+		if got := source[span.node.Start:span.node.End]; got != span.expected {
+			t.Errorf("expected span %q, got %q", span.expected, got)
+		}
+	}
+
+	if second := tree.Body[1].Pos(); second.Line != 3 || second.Column != 1 {
+		t.Errorf("expected the loop at 3:1, got %d:%d", second.Line, second.Column)
+	}
+}
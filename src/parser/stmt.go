@@ -7,8 +7,23 @@ import (
 	"github.com/lucaengelhard/lang/src/lexer"
 )
 
-func parse_stmt(p *parser) ast.Stmt {
+func parse_stmt(p *Parser) (stmt ast.Stmt) {
 	start_pos := p.curentTokenPosition()
+	start := p.index
+
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+
+		if _, ok := recovered.(bailout); !ok {
+			panic(recovered)
+		}
+
+		p.synchronize(start)
+		stmt = ast.BadStmt{Position: p.spanFrom(start_pos)}
+	}()
 
 	stmt_fn, exists := stmt_lu[p.currentTokenKind()]
 
@@ -16,17 +31,20 @@ func parse_stmt(p *parser) ast.Stmt {
 		return stmt_fn(p)
 	}
 
+	if p.currentTokenKind() == lexer.IDENTIFIER && p.peekNextKind() == lexer.COLON {
+		return parse_labeled_stmt(p)
+	}
+
 	expression := parse_expr(p, default_bp)
-	end_pos := p.curentTokenPosition()
 	p.expect(lexer.SEMI_COLON)
 
 	return ast.ExpressionStmt{
 		Expression: expression,
-		Position:   ast.CreatePosition(start_pos.Start, end_pos.End),
+		Position:   p.spanFrom(start_pos),
 	}
 }
 
-func parse_block_stmt(p *parser) ast.BlockStmt {
+func parse_block_stmt(p *Parser) ast.BlockStmt {
 	start_pos := p.curentTokenPosition()
 
 	body := make([]ast.Stmt, 0)
@@ -35,20 +53,13 @@ func parse_block_stmt(p *parser) ast.BlockStmt {
 		body = append(body, parse_stmt(p))
 	}
 
-	// Patch to prohibit crash
-	if !p.hasTokens() {
-		p.index = p.index - 1
-	}
-
-	end_pos := p.curentTokenPosition()
-
 	return ast.BlockStmt{
 		Body:     body,
-		Position: ast.CreatePosition(start_pos.Start, end_pos.End),
+		Position: p.spanFrom(start_pos),
 	}
 }
 
-func parse_declaration_stmt(p *parser) ast.Stmt {
+func parse_declaration_stmt(p *Parser) ast.Stmt {
 	pos := p.curentTokenPosition()
 
 	var explicitType = ast.CreateUnsetType()
@@ -74,20 +85,22 @@ func parse_declaration_stmt(p *parser) ast.Stmt {
 		Identifier:    identifier.Literal,
 		AssignedValue: assignedValue,
 		Type:          explicitType,
-		Position:      pos,
+		Position:      p.spanFrom(pos),
 	}
 }
 
-func parse_struct_properties(p *parser) map[string]ast.StructProperty {
+func parse_struct_properties(p *Parser) map[string]ast.StructProperty {
 	var properties = map[string]ast.StructProperty{}
 
 	for p.hasTokens() && p.currentTokenKind() != lexer.CLOSE_CURLY {
 		var prop_mods = map[string]ast.StructPropertyModifier{}
+		prop_pos := p.curentTokenPosition()
 
 		for p.hasTokens() && p.currentToken().IsReserved() {
 			tok := p.currentToken()
 			prop_mods[tok.Literal] = ast.StructPropertyModifier{
-				Name: tok.Literal,
+				Name:     tok.Literal,
+				Position: ast.TokenPosition(tok),
 			}
 			p.advance()
 		}
@@ -107,13 +120,14 @@ func parse_struct_properties(p *parser) map[string]ast.StructProperty {
 			Name:      prop_name,
 			Type:      prop_type,
 			Modifiers: prop_mods,
+			Position:  p.spanFrom(prop_pos),
 		}
 	}
 
 	return properties
 }
 
-func parse_struct_stmt(p *parser) ast.Stmt {
+func parse_struct_stmt(p *Parser) ast.Stmt {
 	start_pos := p.curentTokenPosition()
 	p.expect(lexer.STRUCT)
 	identifier := p.expect(lexer.IDENTIFIER).Literal
@@ -129,18 +143,17 @@ func parse_struct_stmt(p *parser) ast.Stmt {
 
 	p.expect(lexer.OPEN_CURLY)
 	properties := parse_struct_properties(p)
-	end_pos := p.curentTokenPosition()
 	p.expect(lexer.CLOSE_CURLY)
 
 	return ast.StructStmt{
 		Identifier: identifier,
 		Type:       typeArg,
 		Properties: properties,
-		Position:   ast.CreatePosition(start_pos.Start, end_pos.End),
+		Position:   p.spanFrom(start_pos),
 	}
 }
 
-func parse_interface_stmt(p *parser) ast.Stmt {
+func parse_interface_stmt(p *Parser) ast.Stmt {
 	start_pos := p.curentTokenPosition()
 
 	p.expect(lexer.INTERFACE)
@@ -158,78 +171,137 @@ func parse_interface_stmt(p *parser) ast.Stmt {
 	if p.currentTokenKind() == lexer.ASSIGNMENT {
 		p.advance()
 
-		stmt := ast.InterfaceStmt{
+		singleType := parse_type(p, default_bp)
+		p.expect(lexer.SEMI_COLON)
+
+		return ast.InterfaceStmt{
 			Identifier: identifier,
 			TypeArg:    typeArg,
-			SingleType: parse_type(p, default_bp),
+			SingleType: singleType,
+			Position:   p.spanFrom(start_pos),
 		}
-
-		p.expect(lexer.SEMI_COLON)
-
-		return stmt
 	}
 
 	p.expect(lexer.OPEN_CURLY)
 	structType := parse_struct_properties(p)
-	end_pos := p.curentTokenPosition()
 	p.expect(lexer.CLOSE_CURLY)
 
 	return ast.InterfaceStmt{
 		Identifier: identifier,
 		TypeArg:    typeArg,
 		StructType: structType,
-		Position:   ast.CreatePosition(start_pos.Start, end_pos.End),
+		Position:   p.spanFrom(start_pos),
 		SingleType: ast.CreateUnsetType(),
 	}
 }
 
-func parse_enum_stmt(p *parser) ast.Stmt {
+func parse_enum_stmt(p *Parser) ast.Stmt {
 	start_pos := p.curentTokenPosition()
 
 	p.expect(lexer.ENUM)
 	identifier := p.expect(lexer.IDENTIFIER).Literal
-	var elements = map[string]int{}
+	var elements = []ast.EnumElement{}
 
 	p.expect(lexer.OPEN_CURLY)
 
-	var iota = 0
+	// Elements without a value follow the previous int or get their index if there is none
+	var next int64 = 0
 	for p.hasTokens() && p.currentTokenKind() != lexer.CLOSE_CURLY {
-		elements[p.expect(lexer.IDENTIFIER).Literal] = iota
-		iota++
+		element_pos := p.curentTokenPosition()
+		element_start := p.index
+		name := p.expect(lexer.IDENTIFIER).Literal
+		var value ast.Expr = ast.IntExpr{Value: next, Position: element_pos}
+
+		if p.currentTokenKind() == lexer.ASSIGNMENT {
+			p.advance()
+			value = parse_enum_value(p)
+		}
+
+		for _, element := range elements {
+			if element.Name == name {
+				p.errSince(element_start, fmt.Sprintf("Member %s already exists on enum", name))
+			} else if enum_value(element.Value) == enum_value(value) {
+				p.errSince(element_start, fmt.Sprintf("Value of %s is already used by %s", name, element.Name))
+			}
+		}
+
+		if int_value, is_int := value.(ast.IntExpr); is_int {
+			next = int_value.Value + 1
+		} else {
+			next = int64(len(elements) + 1)
+		}
+
+		elements = append(elements, ast.EnumElement{Name: name, Value: value, Position: p.spanFrom(element_pos)})
+
 		if p.currentTokenKind() != lexer.CLOSE_CURLY {
 			p.expect(lexer.COMMA)
 		}
 	}
 
-	end_pos := p.curentTokenPosition()
-
 	p.expect(lexer.CLOSE_CURLY)
 
 	return ast.EnumStmt{
 		Identifier: identifier,
 		Elements:   elements,
-		Position:   ast.CreatePosition(start_pos.Start, end_pos.End),
+		Position:   p.spanFrom(start_pos),
+	}
+}
+
+// Enum values are int or string literals
+func parse_enum_value(p *Parser) ast.Expr {
+	pos := p.curentTokenPosition()
+	start := p.index
+
+	switch p.currentTokenKind() {
+	case lexer.STRING:
+		return parse_string_expr(p)
+	case lexer.MINUS:
+		p.advance()
+		if p.currentTokenKind() != lexer.NUMBER {
+			break
+		}
+
+		if number, is_int := parse_number_expr(p).(ast.IntExpr); is_int {
+			return ast.IntExpr{Value: -number.Value, Position: p.spanFrom(pos)}
+		}
+	case lexer.NUMBER:
+		if value, is_int := parse_number_expr(p).(ast.IntExpr); is_int {
+			return value
+		}
+	}
+
+	p.errSince(start, "Enum values must be int or string literals")
+	panic(bailout{})
+}
+
+func enum_value(expr ast.Expr) any {
+	switch expr := expr.(type) {
+	case ast.IntExpr:
+		return expr.Value
+	case ast.StringExpr:
+		return expr.Value
 	}
+
+	return nil
 }
 
-func parse_fn_stmt(p *parser) ast.Stmt {
+func parse_fn_stmt(p *Parser) ast.Stmt {
 	start_pos := p.curentTokenPosition()
 
 	p.expect(lexer.FN)
 	identifier := p.expect(lexer.IDENTIFIER)
-
-	end_pos := p.curentTokenPosition()
+	fn := parse_fn_declare_expr(p)
 
 	return ast.DeclarationStmt{
 		Identifier:    identifier.Literal,
 		IsMutable:     false,
-		AssignedValue: parse_fn_declare_expr(p),
-		Position:      ast.CreatePosition(start_pos.Start, end_pos.End),
+		AssignedValue: fn,
+		Position:      p.spanFrom(start_pos),
 		Type:          ast.CreateUnsetType(),
 	}
 }
 
-func parse_if_stmt(p *parser) ast.Stmt {
+func parse_if_stmt(p *Parser) ast.Stmt {
 	start_pos := p.curentTokenPosition()
 
 	p.expect(lexer.IF)
@@ -244,22 +316,38 @@ func parse_if_stmt(p *parser) ast.Stmt {
 	p.expect(lexer.CLOSE_CURLY)
 	if p.currentTokenKind() == lexer.ELSE {
 		p.advance()
-		p.expect(lexer.OPEN_CURLY)
-		false_stmt = parse_block_stmt(p)
-		p.expect(lexer.CLOSE_CURLY)
-	}
 
-	end_pos := p.curentTokenPosition()
+		// else if is an else block that only contains the next if
+		if p.currentTokenKind() == lexer.IF {
+			else_if := parse_if_stmt(p)
+			false_stmt = ast.BlockStmt{Body: []ast.Stmt{else_if}, Position: else_if.Pos()}
+		} else {
+			p.expect(lexer.OPEN_CURLY)
+			false_stmt = parse_block_stmt(p)
+			p.expect(lexer.CLOSE_CURLY)
+		}
+	}
 
 	return ast.IfStmt{
 		Condition: cond,
 		True:      true_stmt,
 		False:     false_stmt,
-		Position:  ast.CreatePosition(start_pos.Start, end_pos.End),
+		Position:  p.spanFrom(start_pos),
+	}
+}
+
+func parse_switch_stmt(p *Parser) ast.Stmt {
+	start_pos := p.curentTokenPosition()
+	subject, arms := parse_switch(p, true)
+
+	return ast.SwitchStmt{
+		Subject:  subject,
+		Arms:     arms,
+		Position: p.spanFrom(start_pos),
 	}
 }
 
-func parse_while_stmt(p *parser) ast.Stmt {
+func parse_while_stmt(p *Parser) ast.Stmt {
 	start_pos := p.curentTokenPosition()
 
 	p.expect(lexer.WHILE)
@@ -269,21 +357,25 @@ func parse_while_stmt(p *parser) ast.Stmt {
 
 	p.expect(lexer.OPEN_CURLY)
 	body := parse_block_stmt(p)
-	end_pos := p.curentTokenPosition()
 	p.expect(lexer.CLOSE_CURLY)
 
 	return ast.WhileStmt{
 		Condition: cond,
 		Body:      body,
-		Position:  ast.CreatePosition(start_pos.Start, end_pos.End),
+		Position:  p.spanFrom(start_pos),
 	}
 }
 
-func parse_for_stmt(p *parser) ast.Stmt {
+func parse_for_stmt(p *Parser) ast.Stmt {
 	start_pos := p.curentTokenPosition()
 
 	p.expect(lexer.FOR)
 	p.expect(lexer.OPEN_PAREN)
+
+	if p.currentTokenKind() == lexer.IDENTIFIER && (p.peekNextKind() == lexer.IN || p.peekNextKind() == lexer.COMMA) {
+		return parse_for_in_stmt(p, start_pos)
+	}
+
 	assignemt := parse_stmt(p)
 	cond := parse_stmt(p)
 	incr := parse_expr(p, default_bp)
@@ -291,7 +383,6 @@ func parse_for_stmt(p *parser) ast.Stmt {
 
 	p.expect(lexer.OPEN_CURLY)
 	body := parse_block_stmt(p)
-	end_pos := p.curentTokenPosition()
 	p.expect(lexer.CLOSE_CURLY)
 
 	return ast.ForStmt{
@@ -299,11 +390,38 @@ func parse_for_stmt(p *parser) ast.Stmt {
 		Condition:  cond,
 		Increment:  incr,
 		Body:       body,
-		Position:   ast.CreatePosition(start_pos.Start, end_pos.End),
+		Position:   p.spanFrom(start_pos),
 	}
 }
 
-func parse_return_stmt(p *parser) ast.Stmt {
+// Parses the rest of for (el, index in xs) { ... } after the opening paren
+func parse_for_in_stmt(p *Parser, start_pos ast.Position) ast.Stmt {
+	element := p.expect(lexer.IDENTIFIER).Literal
+	var index string
+
+	if p.currentTokenKind() == lexer.COMMA {
+		p.advance()
+		index = p.expect(lexer.IDENTIFIER).Literal
+	}
+
+	p.expect(lexer.IN)
+	iterable := parse_expr(p, default_bp)
+	p.expect(lexer.CLOSE_PAREN)
+
+	p.expect(lexer.OPEN_CURLY)
+	body := parse_block_stmt(p)
+	p.expect(lexer.CLOSE_CURLY)
+
+	return ast.ForInStmt{
+		Element:  element,
+		Index:    index,
+		Iterable: iterable,
+		Body:     body,
+		Position: p.spanFrom(start_pos),
+	}
+}
+
+func parse_return_stmt(p *Parser) ast.Stmt {
 	pos := p.curentTokenPosition()
 	p.expect(lexer.RETURN)
 	expr := parse_expr(p, logical)
@@ -311,25 +429,60 @@ func parse_return_stmt(p *parser) ast.Stmt {
 
 	return ast.ReturnStmt{
 		Value:    expr,
-		Position: pos,
+		Position: p.spanFrom(pos),
 	}
 }
 
-func parse_continue_stmt(p *parser) ast.Stmt {
+func parse_continue_stmt(p *Parser) ast.Stmt {
 	pos := p.curentTokenPosition()
 	p.expect(lexer.CONTINUE)
+	label := parse_optional_label(p)
 	p.expect(lexer.SEMI_COLON)
-	return ast.ContinueStmt{Position: pos}
+	return ast.ContinueStmt{Label: label, Position: p.spanFrom(pos)}
 }
 
-func parse_break_stmt(p *parser) ast.Stmt {
+func parse_break_stmt(p *Parser) ast.Stmt {
 	pos := p.curentTokenPosition()
 	p.expect(lexer.BREAK)
+	label := parse_optional_label(p)
 	p.expect(lexer.SEMI_COLON)
-	return ast.BreakStmt{Position: pos}
+	return ast.BreakStmt{Label: label, Position: p.spanFrom(pos)}
 }
 
-func parse_import_stmt(p *parser) ast.Stmt {
+func parse_optional_label(p *Parser) string {
+	if p.currentTokenKind() != lexer.IDENTIFIER {
+		return ""
+	}
+
+	return p.advance().Literal
+}
+
+// Parses label: loop
+func parse_labeled_stmt(p *Parser) ast.Stmt {
+	label := p.expect(lexer.IDENTIFIER).Literal
+	p.expect(lexer.COLON)
+
+	switch p.currentTokenKind() {
+	case lexer.FOR:
+		switch loop := parse_for_stmt(p).(type) {
+		case ast.ForStmt:
+			loop.Label = label
+			return loop
+		case ast.ForInStmt:
+			loop.Label = label
+			return loop
+		}
+	case lexer.WHILE:
+		loop := parse_while_stmt(p).(ast.WhileStmt)
+		loop.Label = label
+		return loop
+	}
+
+	p.fail(fmt.Sprintf("Only loops can be labeled, got %s", p.currentTokenKind().ToString()))
+	return nil
+}
+
+func parse_import_stmt(p *Parser) ast.Stmt {
 	start_pos := p.curentTokenPosition()
 	p.expect(lexer.IMPORT)
 	path := p.expect(lexer.STRING).Literal
@@ -352,12 +505,10 @@ func parse_import_stmt(p *parser) ast.Stmt {
 		identifier = p.expect(lexer.IDENTIFIER).Literal
 	}
 
-	end_pos := p.curentTokenPosition()
-
 	return ast.ImportStmt{
 		Path:       path,
 		Identifier: identifier,
 		Items:      items,
-		Position:   ast.CreatePosition(start_pos.Start, end_pos.End),
+		Position:   p.spanFrom(start_pos),
 	}
 }
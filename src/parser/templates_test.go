@@ -0,0 +1,55 @@
+package parser_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/lucaengelhard/lang/src/ast"
+)
+
+func parse_template(t *testing.T, source string) ast.StringTemplateExpr {
+	t.Helper()
+	tree, errors := parse(t, source)
+
+	if len(errors) > 0 {
+		t.Fatalf("unexpected error in %q: %s", source, errors[0].Message)
+	}
+
+	return tree.Body[0].(ast.ExpressionStmt).Expression.(ast.StringTemplateExpr)
+}
+
+func TestTemplateParts(t *testing.T) {
+	template := parse_template(t, `"a {x} b {y + 1} c";`)
+
+	if fmt.Sprintf("%q", template.Parts) != `["a " " b " " c"]` {
+		t.Errorf("unexpected parts %q", template.Parts)
+	}
+
+	if len(template.Expressions) != 2 {
+		t.Fatalf("expected two expressions, got %d", len(template.Expressions))
+	}
+
+	if _, is_binary := template.Expressions[1].(ast.BinaryExpr); !is_binary {
+		t.Errorf("expected y + 1 to be a binary expression, got %T", template.Expressions[1])
+	}
+}
+
+func TestNestedTemplates(t *testing.T) {
+	template := parse_template(t, `"a { "b {2} c" } d";`)
+	inner, is_template := template.Expressions[0].(ast.StringTemplateExpr)
+
+	if !is_template || fmt.Sprintf("%q", inner.Parts) != `["b " " c"]` {
+		t.Errorf("expected a nested template, got %#v", template.Expressions[0])
+	}
+
+	template = parse_template(t, `"x { {a: 1}.a }";`)
+
+	if _, is_chain := template.Expressions[0].(ast.ChainExpr); !is_chain {
+		t.Errorf("expected a member access of a record, got %T", template.Expressions[0])
+	}
+}
+
+func TestTemplateErrors(t *testing.T) {
+	expect_errors(t, `"x {1 2}";`, [2]string{"Expected end of embedded expression but recieved number instead", "2"})
+	expect_errors(t, `"x {}";`, [2]string{"Unexpected token (nud) near: template_tail ()", "}\""})
+}
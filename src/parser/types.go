@@ -7,8 +7,8 @@ import (
 	"github.com/lucaengelhard/lang/src/lexer"
 )
 
-type type_nud_handler func(p *parser) ast.Type
-type type_led_handler func(p *parser, left ast.Type, bp binding_power) ast.Type
+type type_nud_handler func(p *Parser) ast.Type
+type type_led_handler func(p *Parser, left ast.Type, bp binding_power) ast.Type
 
 type type_nud_lookup map[lexer.TokenKind]type_nud_handler
 type type_led_lookup map[lexer.TokenKind]type_led_handler
@@ -33,16 +33,15 @@ func createTokenTypeLookups() {
 	type_nud(lexer.STAR, parse_ref_type)
 	/* 	type_nud(lexer.NUMBER, parse_number_type)
 	   	type_nud(lexer.STRING, parse_string_type) */
-	//type_nud(lexer.OPEN_PAREN, parse_fn_type)
+	type_nud(lexer.OPEN_PAREN, parse_fn_type)
 }
 
-func parse_type(p *parser, bp binding_power) ast.Type {
+func parse_type(p *Parser, bp binding_power) ast.Type {
 	tokenKind := p.currentTokenKind()
 	nud_fn, exists := type_nud_lu[tokenKind]
 
 	if !exists {
-		p.err(fmt.Sprintf("Type Nud handler expected for token %s\n", tokenKind.ToString()))
-		return ast.CreateUnsetType()
+		p.fail(fmt.Sprintf("Type Nud handler expected for token %s\n", tokenKind.ToString()))
 	}
 
 	left := nud_fn(p)
@@ -52,8 +51,7 @@ func parse_type(p *parser, bp binding_power) ast.Type {
 		led_fn, exists := type_led_lu[tokenKind]
 
 		if !exists {
-			p.err(fmt.Sprintf("Type Led handler expected for token %s\n", tokenKind.ToString()))
-			return ast.CreateUnsetType()
+			p.fail(fmt.Sprintf("Type Led handler expected for token %s\n", tokenKind.ToString()))
 		}
 
 		left = led_fn(p, left, type_bp_lu[p.currentTokenKind()])
@@ -62,7 +60,7 @@ func parse_type(p *parser, bp binding_power) ast.Type {
 	return left
 }
 
-func parse_symbol_type(p *parser) ast.Type {
+func parse_symbol_type(p *Parser) ast.Type {
 	ident := p.expect(lexer.IDENTIFIER)
 	args := make([]ast.Type, 0)
 
@@ -81,17 +79,17 @@ func parse_symbol_type(p *parser) ast.Type {
 	return ast.Type{Name: ident.Literal, Arguments: args}
 }
 
-func parse_ref_type(p *parser) ast.Type {
+func parse_ref_type(p *Parser) ast.Type {
 	p.expect(lexer.STAR)
 
 	return ast.Type{Name: ast.REFERENCE, Arguments: []ast.Type{parse_type(p, logical)}}
 }
 
-/* func parse_string_type(p *parser) typechecker.Type {
+/* func parse_string_type(p *Parser) typechecker.Type {
 	return typechecker.Type{Name: "string"}
 }
 
-func parse_number_type(p *parser) typechecker.Type {
+func parse_number_type(p *Parser) typechecker.Type {
 	val := p.expect(lexer.NUMBER).Literal
 	if _, err := strconv.ParseInt(val, 10, 64); err == nil {
 		return typechecker.Type{Name: "int"}
@@ -100,36 +98,34 @@ func parse_number_type(p *parser) typechecker.Type {
 	return typechecker.Type{Name: "float"}
 } */
 
-/* func parse_fn_type(p *parser) typechecker.Type {
-	var arguments = map[string]ast.FnArg{}
+// Function types are written like the arguments and return type of a function declaration: (x: int, mut y: *int) -> int
+func parse_fn_type(p *Parser) ast.Type {
+	var arguments = []ast.Type{}
 
 	p.expect(lexer.OPEN_PAREN)
 
 	for p.hasTokens() && p.currentTokenKind() != lexer.CLOSE_PAREN {
-		var explicitType ast.Type
 		isMutable := p.currentTokenKind() == lexer.MUT
 		if isMutable {
 			p.advance()
 		}
 
 		argumentIdentifier := p.expect(lexer.IDENTIFIER).Literal
-		if p.currentTokenKind() == lexer.COLON {
-			p.advance()
-			explicitType = parse_type(p, default_bp)
-		}
+		p.expect(lexer.COLON)
+		explicitType := parse_type(p, default_bp)
 
-		_, exists := arguments[argumentIdentifier]
-
-		if exists {
-			p.err(fmt.Sprintf("Argument %s already exists in function type", argumentIdentifier))
+		if isMutable {
+			explicitType = explicitType.WrapUnder(ast.MUTABLE, ast.REFERENCE)
 		}
 
-		arguments[argumentIdentifier] = ast.FnArg{
-			Identifier: argumentIdentifier,
-			IsMutable:  isMutable,
-			Type:       explicitType,
+		for _, arg := range arguments {
+			if arg.Name == argumentIdentifier {
+				p.err(fmt.Sprintf("Argument %s already exists in function type", argumentIdentifier))
+			}
 		}
 
+		arguments = append(arguments, ast.Type{Name: argumentIdentifier, Arguments: []ast.Type{explicitType}})
+
 		if p.currentTokenKind() != lexer.CLOSE_PAREN {
 			p.expect(lexer.COMMA)
 		}
@@ -137,15 +133,18 @@ func parse_number_type(p *parser) typechecker.Type {
 
 	p.expect(lexer.CLOSE_PAREN)
 
-	var returnType typechecker.Type
+	var returnType = ast.CreateUnsetType()
 
 	if p.currentTokenKind() == lexer.R_ARROW {
 		p.advance()
 		returnType = parse_type(p, default_bp)
 	}
 
-	return ast.FnType{
-		Arguments:  arguments,
-		ReturnType: returnType,
+	return ast.Type{
+		Name: ast.FUNCTION,
+		Arguments: []ast.Type{
+			{Name: ast.FUNCTION_ARG, Arguments: arguments},
+			{Name: ast.FUNCTION_RETURN, Arguments: []ast.Type{returnType}},
+		},
 	}
-} */
+}
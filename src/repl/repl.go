@@ -0,0 +1,279 @@
+package repl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/lucaengelhard/lang/src/ast"
+	"github.com/lucaengelhard/lang/src/errorhandling"
+	"github.com/lucaengelhard/lang/src/interpreter"
+	"github.com/lucaengelhard/lang/src/lexer"
+	"github.com/lucaengelhard/lang/src/parser"
+	"github.com/lucaengelhard/lang/src/typechecker"
+	"github.com/sanity-io/litter"
+)
+
+const (
+	prompt              = ">> "
+	continuation_prompt = ".. "
+)
+
+const help = `Enter statements or expressions. Expression results are printed with their type.
+A trailing ';' is optional.
+
+  :type <expr>   Print the type of an expression without evaluating it
+  :ast <expr>    Print the syntax tree of an expression
+  :help          Print this help
+  :quit          Leave the repl
+`
+
+type repl struct {
+	types   *typechecker.Checker
+	values  *interpreter.Env
+	out     io.Writer
+	options errorhandling.Options
+}
+
+// Start reads inputs from in until it's exhausted or :quit is entered.
+// Declarations made by an input are visible to all following inputs.
+func Start(in io.Reader, out io.Writer) {
+	r := &repl{
+		types:   typechecker.New(),
+		values:  interpreter.CreateEnv(),
+		out:     out,
+		options: errorhandling.Options{Format: errorhandling.TEXT},
+	}
+	r.values.SetOutput(out)
+
+	// Only highlight errors if they are written to the terminal
+	if out == io.Writer(os.Stdout) {
+		r.options = errorhandling.DefaultOptions()
+	}
+
+	scanner := bufio.NewScanner(in)
+	var buffer strings.Builder
+
+	for {
+		if buffer.Len() == 0 {
+			fmt.Fprint(out, prompt)
+		} else {
+			fmt.Fprint(out, continuation_prompt)
+		}
+
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return
+		}
+
+		line := scanner.Text()
+
+		if buffer.Len() == 0 && strings.HasPrefix(strings.TrimSpace(line), ":") {
+			if !r.meta(strings.TrimSpace(line)) {
+				return
+			}
+			continue
+		}
+
+		buffer.WriteString(line)
+		buffer.WriteString("\n")
+
+		if is_incomplete(buffer.String()) {
+			continue
+		}
+
+		if strings.TrimSpace(buffer.String()) != "" {
+			r.eval(buffer.String())
+		}
+		buffer.Reset()
+	}
+}
+
+// Returns false if the repl should be left
+func (r *repl) meta(line string) bool {
+	command, argument, _ := strings.Cut(line, " ")
+	argument = strings.TrimSpace(argument)
+
+	switch command {
+	case ":q", ":quit", ":exit":
+		return false
+	case ":h", ":help":
+		fmt.Fprint(r.out, help)
+	case ":t", ":type":
+		expr, ok := r.parse_expr(argument)
+		if !ok {
+			return true
+		}
+
+		r.protect(func() {
+			r.types.NextInput()
+			computed, errors := r.types.Check(expr)
+			if len(errors) > 0 {
+				r.report(argument, errors)
+				return
+			}
+			fmt.Fprintln(r.out, computed.ToString())
+		})
+	case ":ast":
+		expr, ok := r.parse_expr(argument)
+		if !ok {
+			return true
+		}
+
+		fmt.Fprintln(r.out, litter.Sdump(expr.Expression))
+	default:
+		fmt.Fprintf(r.out, "Unknown command %s (see :help)\n", command)
+	}
+
+	return true
+}
+
+// The whole input is checked before any of it is evaluated. If it fails,
+// the checker forgets the declarations that never reached the interpreter.
+func (r *repl) eval(source string) {
+	block, errors := r.parse(source)
+	r.types.NextInput()
+
+	if len(errors) > 0 || len(block.Body) == 0 {
+		r.report(source, errors)
+		return
+	}
+
+	snapshots := make([]typechecker.Snapshot, len(block.Body))
+	types := make([]ast.Type, len(block.Body))
+
+	checked := r.protect(func() {
+		for index, stmt := range block.Body {
+			snapshots[index] = r.types.Snapshot()
+
+			var stmt_errors []errorhandling.Error
+			types[index], stmt_errors = r.types.Check(stmt)
+			errors = append(errors, stmt_errors...)
+		}
+	})
+
+	if !checked || len(errors) > 0 {
+		r.types.Restore(snapshots[0])
+		r.report(source, errors)
+		return
+	}
+
+	for index, stmt := range block.Body {
+		var value any
+		evaluated := r.protect(func() { value, errors = r.values.Eval(stmt) })
+
+		if !evaluated || len(errors) > 0 {
+			r.types.Restore(snapshots[index])
+			r.report(source, errors)
+			return
+		}
+
+		if _, isExpression := stmt.(ast.ExpressionStmt); isExpression && value != nil {
+			r.print_value(value, types[index])
+		}
+	}
+}
+
+func (r *repl) report(source string, errors []errorhandling.Error) {
+	errorhandling.WriteErrors(r.out, source, errors, r.options)
+}
+
+func (r *repl) print_value(value any, computed ast.Type) {
+	if computed.IsUnset() {
+		fmt.Fprintln(r.out, interpreter.FormatValue(value))
+		return
+	}
+
+	fmt.Fprintf(r.out, "%s : %s\n", interpreter.FormatValue(value), computed.ToString())
+}
+
+// Parses an input. Inputs without a trailing semicolon are accepted
+// if they would be valid with one.
+func (r *repl) parse(source string) (ast.BlockStmt, []errorhandling.Error) {
+	block, errors := parse_source(source)
+
+	if len(errors) > 0 {
+		if completed, completed_errors := parse_source(source + ";"); len(completed_errors) == 0 {
+			return completed, nil
+		}
+	}
+
+	return block, errors
+}
+
+func (r *repl) parse_expr(source string) (ast.ExpressionStmt, bool) {
+	block, errors := r.parse(source)
+
+	if len(errors) > 0 {
+		r.report(source, errors)
+		return ast.ExpressionStmt{}, false
+	}
+
+	if len(block.Body) != 1 {
+		fmt.Fprintln(r.out, "Expected a single expression")
+		return ast.ExpressionStmt{}, false
+	}
+
+	expr, ok := block.Body[0].(ast.ExpressionStmt)
+
+	if !ok {
+		fmt.Fprintln(r.out, "Expected an expression, got a statement")
+	}
+
+	return expr, ok
+}
+
+func parse_source(source string) (block ast.BlockStmt, errors []errorhandling.Error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			errors = append(errors, errorhandling.Error{Message: fmt.Sprint(recovered), Phase: errorhandling.PARSER})
+		}
+	}()
+
+	tokens, errors := lexer.Tokenize(source)
+
+	if len(errors) > 0 {
+		return ast.BlockStmt{}, errors
+	}
+
+	tree, errors := parser.Parse(tokens)
+	block, _ = tree.(ast.BlockStmt)
+
+	return block, errors
+}
+
+// Runs fn and reports a panic instead of crashing the repl
+func (r *repl) protect(fn func()) (ok bool) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			fmt.Fprintf(r.out, "Error: %v\n", recovered)
+			ok = false
+		}
+	}()
+
+	fn()
+	return true
+}
+
+// An input is incomplete as long as a paren, bracket or curly is still open
+func is_incomplete(source string) bool {
+	tokens, errors := lexer.Tokenize(source)
+
+	if len(errors) > 0 {
+		return false
+	}
+
+	var depth = 0
+	for _, token := range tokens {
+		switch token.Kind {
+		case lexer.OPEN_PAREN, lexer.OPEN_BRACKET, lexer.OPEN_CURLY:
+			depth++
+		case lexer.CLOSE_PAREN, lexer.CLOSE_BRACKET, lexer.CLOSE_CURLY:
+			depth--
+		}
+	}
+
+	return depth > 0
+}
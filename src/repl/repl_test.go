@@ -0,0 +1,133 @@
+package repl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func run(input string) string {
+	var out bytes.Buffer
+	Start(strings.NewReader(input), &out)
+	return out.String()
+}
+
+func TestDeclarationsPersist(t *testing.T) {
+	out := run("let a = 1;\na + 2\n")
+
+	if !strings.Contains(out, "3 : int") {
+		t.Errorf("expected the result of a + 2, got %q", out)
+	}
+}
+
+func TestOptionalSemicolon(t *testing.T) {
+	out := run("let a = \"x\"\na\n")
+
+	if !strings.Contains(out, "x : string") {
+		t.Errorf("expected the value of a, got %q", out)
+	}
+}
+
+func TestMultiLineInput(t *testing.T) {
+	out := run("fn add(a: int, b: int) -> int {\nreturn a + b;\n}\nadd(1, 2)\n")
+
+	if !strings.Contains(out, continuation_prompt) {
+		t.Errorf("expected a continuation prompt, got %q", out)
+	}
+
+	if !strings.Contains(out, "3 : int") {
+		t.Errorf("expected the result of the call, got %q", out)
+	}
+}
+
+func TestErrorsAreWrittenToOut(t *testing.T) {
+	out := run("let a: int = \"x\";\n")
+
+	if !strings.Contains(out, "Type error") {
+		t.Errorf("expected a type error, got %q", out)
+	}
+}
+
+func TestFailedInputIsForgotten(t *testing.T) {
+	out := run("let a: int = \"x\";\nlet a = 2;\na\n")
+
+	if !strings.Contains(out, "2 : int") {
+		t.Errorf("expected a to be declared by the second input, got %q", out)
+	}
+}
+
+func TestRuntimeErrorsDontEndTheRepl(t *testing.T) {
+	out := run("1 / 0\n1 + 1\n")
+
+	if !strings.Contains(out, "Integer division by zero") {
+		t.Errorf("expected a runtime error, got %q", out)
+	}
+
+	if !strings.Contains(out, "2 : int") {
+		t.Errorf("expected the repl to continue after the error, got %q", out)
+	}
+}
+
+func TestTypeCommand(t *testing.T) {
+	out := run(":type 1 + 1.5\n")
+
+	if !strings.Contains(out, "float") {
+		t.Errorf("expected the type of the expression, got %q", out)
+	}
+}
+
+func TestQuit(t *testing.T) {
+	out := run(":quit\n1 + 1\n")
+
+	if strings.Contains(out, "2 : int") {
+		t.Errorf("expected no input to be evaluated after :quit, got %q", out)
+	}
+}
+
+func TestLabelsOfEarlierInputsAreDropped(t *testing.T) {
+	out := run("let a = 1;\na = \"s\";\n")
+
+	if strings.Contains(out, "declared here") {
+		t.Errorf("expected no label pointing at the first input, got %q", out)
+	}
+
+	out = run("let a = 1; a = \"s\";\n")
+
+	if !strings.Contains(out, "a declared here") {
+		t.Errorf("expected a label pointing at the declaration in the same input, got %q", out)
+	}
+}
+
+func TestInputIsCheckedBeforeItIsEvaluated(t *testing.T) {
+	out := run("println(\"side effect\"); let b: int = \"x\";\n")
+
+	if strings.Contains(out, ">> side effect") {
+		t.Errorf("expected nothing to be evaluated, got %q", out)
+	}
+
+	if !strings.Contains(out, "Type error") {
+		t.Errorf("expected a type error, got %q", out)
+	}
+}
+
+func TestDeclarationsOfFailedInputsAreForgotten(t *testing.T) {
+	out := run("let a = 1; let b: int = \"x\";\nlet a = \"s\";\na\n")
+
+	if !strings.Contains(out, "s : string") {
+		t.Errorf("expected a to be declared by the second input, got %q", out)
+	}
+
+	out = run("let a = 1 / 0; let b = 2;\nlet a = 3; let b = 4;\na + b\n")
+
+	if !strings.Contains(out, "7 : int") {
+		t.Errorf("expected the declarations after the runtime error to be forgotten, got %q", out)
+	}
+}
+
+func TestProgramOutputIsWrittenToOut(t *testing.T) {
+	out := run("println(\"hello\")\n")
+
+	if !strings.Contains(out, ">> hello\n") {
+		t.Errorf("expected the output of println, got %q", out)
+	}
+}
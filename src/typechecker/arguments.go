@@ -0,0 +1,232 @@
+package typechecker
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/lucaengelhard/lang/src/ast"
+	"github.com/lucaengelhard/lang/src/errorhandling"
+)
+
+// An argument of a call after records and arrays were spread into the arguments
+type call_argument struct {
+	// Empty for positional arguments
+	name     string
+	value    ast.Type
+	position ast.Position
+	// Index of the argument in the call
+	argument int
+	// Set for fields of spread records
+	field string
+	// Set for arrays spread over positional parameters
+	spread bool
+	// Types of the single elements of a spread array literal, nil if they aren't known
+	elements []ast.Type
+}
+
+// Records and arrays passed where their parameter can't take them are
+// spread into the arguments. Records stand in for named arguments, arrays
+// for positional ones.
+func spread_arguments(node ast.FnCallExpr, params []ast.Type, env *env) []call_argument {
+	args := make([]call_argument, 0, len(node.Arguments))
+	positional := 0
+	named := false
+
+	for index, arg := range node.Arguments {
+		// Mutability belongs to the variable, not to the value passed
+		var computed ast.Type
+		var elements []ast.Type
+
+		// The elements of array literals are kept in case the array is spread
+		if literal, is_literal := arg.Value.(ast.ArrayInstantiationExpr); is_literal {
+			elements = make([]ast.Type, 0, len(literal.Elements))
+			for _, el := range literal.Elements {
+				elements = append(elements, check(el, env).Strip(ast.MUTABLE))
+			}
+			computed = unify_elements(elements).Wrap(ast.ARRAY)
+		} else {
+			computed = check(arg.Value, env).Strip(ast.MUTABLE)
+		}
+
+		argument := call_argument{name: arg.Identifier, value: computed, position: arg.Position, argument: index}
+		accepted := positional < len(params) && match(params[positional].Arguments[0].Strip(ast.MUTABLE), computed)
+		// Arrays passed for array parameters are never spread
+		expects_array := positional < len(params) && params[positional].Arguments[0].Strip(ast.MUTABLE).Is(ast.ARRAY)
+
+		switch {
+		case arg.Identifier != "":
+			named = true
+		case named:
+			env.err(arg.Position, "Positional arguments can't follow named arguments")
+			continue
+		case computed.Strip(ast.MUTABLE).Is(ast.DICT) && !accepted:
+			named = true
+			for _, prop := range computed.Strip(ast.MUTABLE).Arguments {
+				args = append(args, call_argument{name: prop.Name, value: prop.Arguments[0], position: arg.Position, argument: index, field: prop.Name})
+			}
+			continue
+		case computed.Strip(ast.MUTABLE).Is(ast.ARRAY) && !accepted && !expects_array:
+			if slices.ContainsFunc(args, func(a call_argument) bool { return a.spread }) {
+				env.err(arg.Position, "Only one array can be spread into the arguments of a call")
+				continue
+			}
+
+			argument.spread = true
+			argument.elements = elements
+		default:
+			positional++
+		}
+
+		args = append(args, argument)
+	}
+
+	return args
+}
+
+// Binds the arguments of a call to the parameters of the function and checks
+// their types. Positional arguments are bound in order, named arguments by
+// name, and can only be followed by other named arguments. A spread array
+// binds the parameters the other arguments leave free, positional arguments
+// after it bind the last free parameters. The binding is kept on the call
+// for the interpreter.
+func check_arguments(node ast.FnCallExpr, params []ast.Type, name string, env *env) {
+	args := spread_arguments(node, params, env)
+	bound := make([]*call_argument, len(params))
+	bindings := []ast.ArgumentBinding{}
+
+	bind := func(arg *call_argument, param int, element int) {
+		if previous := bound[param]; previous != nil {
+			env.err(arg.position, fmt.Sprintf("Argument %s is already bound", params[param].Name), errorhandling.Label{
+				Position: previous.position.Start,
+				End:      previous.position.End,
+				Message:  "bound here",
+			})
+			return
+		}
+
+		bound[param] = arg
+		expected := params[param].Arguments[0].Strip(ast.MUTABLE)
+		binding := ast.ArgumentBinding{Param: params[param].Name, Argument: arg.argument, Field: arg.field, Element: element}
+
+		if !arg.spread && !match(expected, arg.value) {
+			env.err(arg.position, fmt.Sprintf("Mismatched argument (%d). Expected %s, got %s", param, expected.ToString(), arg.value.ToString()), env.declared_here(name)...)
+		}
+
+		if arg.spread && arg.elements == nil && !match(expected, array_element(arg.value.Strip(ast.MUTABLE))) {
+			binding.Expected = &expected
+		}
+
+		bindings = append(bindings, binding)
+	}
+
+	spread := slices.IndexFunc(args, func(arg call_argument) bool { return arg.spread })
+	positional := slices.IndexFunc(args, func(arg call_argument) bool { return arg.name != "" })
+	if positional < 0 {
+		positional = len(args)
+	}
+
+	before := positional
+	if spread >= 0 {
+		before = spread
+	}
+
+	for index := range args[:before] {
+		if index >= len(params) {
+			env.err(args[index].position, fmt.Sprintf("Too many arguments. Expected %d, got %d", len(params), len(node.Arguments)), env.declared_here(name)...)
+			continue
+		}
+
+		bind(&args[index], index, -1)
+	}
+
+	if spread >= 0 {
+		free := []int{}
+		for param := before; param < len(params); param++ {
+			if !slices.ContainsFunc(args[positional:], func(arg call_argument) bool { return arg.name == params[param].Name }) {
+				free = append(free, param)
+			}
+		}
+
+		after := args[spread+1 : positional]
+		covered := max(len(free)-len(after), 0)
+		check_spread(&args[spread], params, free[:covered], env)
+
+		for element, param := range free[:covered] {
+			bind(&args[spread], param, element)
+		}
+
+		for index := range after {
+			if covered+index >= len(free) {
+				env.err(after[index].position, fmt.Sprintf("Too many arguments. Expected %d, got %d", len(params), len(node.Arguments)), env.declared_here(name)...)
+				continue
+			}
+
+			bind(&after[index], free[covered+index], -1)
+		}
+	}
+
+	for index := range args[positional:] {
+		arg := &args[positional+index]
+		param := slices.IndexFunc(params, func(p ast.Type) bool { return p.Name == arg.name })
+
+		if param < 0 {
+			env.err(arg.position, fmt.Sprintf("Argument %s doesn't exist on %s", arg.name, name), env.declared_here(name)...)
+			continue
+		}
+
+		bind(arg, param, -1)
+	}
+
+	if node.Binding != nil {
+		receiver := false
+		if len(node.Arguments) > 0 {
+			_, receiver = node.Arguments[0].Value.(checked_receiver)
+		}
+
+		*node.Binding = ast.CallBinding{Resolved: true, Receiver: receiver, Arguments: bindings}
+	}
+
+	missing := []string{}
+	for param, arg := range bound {
+		if arg == nil {
+			missing = append(missing, params[param].Name)
+		}
+	}
+
+	if len(missing) == 1 {
+		env.err(node.Position, fmt.Sprintf("Missing argument %s", missing[0]), env.declared_here(name)...)
+	} else if len(missing) > 1 {
+		env.err(node.Position, fmt.Sprintf("Missing arguments %s", strings.Join(missing, ", ")), env.declared_here(name)...)
+	}
+}
+
+// The elements of array literals are checked one by one. For other arrays
+// only the length is unknown, it is checked at runtime, and the element type
+// needs to be able to match every parameter it covers.
+func check_spread(arg *call_argument, params []ast.Type, covered []int, env *env) {
+	if arg.elements != nil {
+		if len(arg.elements) != len(covered) {
+			env.err(arg.position, fmt.Sprintf("Array with %d elements is spread over %d arguments", len(arg.elements), len(covered)))
+			return
+		}
+
+		for i, param := range covered {
+			expected := params[param].Arguments[0].Strip(ast.MUTABLE)
+			if !match(expected, arg.elements[i]) {
+				env.err(arg.position, fmt.Sprintf("Mismatched argument (%d). Expected %s, got %s", param, expected.ToString(), arg.elements[i].ToString()))
+			}
+		}
+
+		return
+	}
+
+	element := array_element(arg.value.Strip(ast.MUTABLE))
+
+	for _, param := range covered {
+		expected := params[param].Arguments[0].Strip(ast.MUTABLE)
+		if !element.IsUnset() && !match(expected, element) && !match(element, expected) {
+			env.err(arg.position, fmt.Sprintf("Elements of type %s can't be passed as argument %s of type %s", element.ToString(), params[param].Name, expected.ToString()))
+		}
+	}
+}
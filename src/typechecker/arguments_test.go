@@ -0,0 +1,86 @@
+package typechecker_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lucaengelhard/lang/src/ast"
+	"github.com/lucaengelhard/lang/src/typechecker"
+)
+
+const tens = `fn tens(a: int, b: int, c: int) -> int { return a * 100 + b * 10 + c; }`
+
+// Checks the source and returns the binding of the call in its last statement
+func binding(t *testing.T, source string) ast.CallBinding {
+	t.Helper()
+	tree := parse(t, source).(ast.BlockStmt)
+
+	if _, errors := typechecker.New().Check(tree); len(errors) > 0 {
+		t.Fatalf("unexpected error in %q: %s", source, errors[0].Message)
+	}
+
+	call := tree.Body[len(tree.Body)-1].(ast.ExpressionStmt).Expression.(ast.FnCallExpr)
+	return *call.Binding
+}
+
+func TestCallsKeepTheirBinding(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected ast.CallBinding
+	}{
+		{`tens(1, c: 3, b: 2);`, ast.CallBinding{Resolved: true, Arguments: []ast.ArgumentBinding{
+			{Param: "a", Argument: 0, Element: -1},
+			{Param: "c", Argument: 1, Element: -1},
+			{Param: "b", Argument: 2, Element: -1},
+		}}},
+		{`tens({ c: 3, a: 1 }, b: 2);`, ast.CallBinding{Resolved: true, Arguments: []ast.ArgumentBinding{
+			{Param: "c", Argument: 0, Field: "c", Element: -1},
+			{Param: "a", Argument: 0, Field: "a", Element: -1},
+			{Param: "b", Argument: 1, Element: -1},
+		}}},
+		{`tens([1, 2], 3);`, ast.CallBinding{Resolved: true, Arguments: []ast.ArgumentBinding{
+			{Param: "a", Argument: 0, Element: 0},
+			{Param: "b", Argument: 0, Element: 1},
+			{Param: "c", Argument: 1, Element: -1},
+		}}},
+		{`let x = 1; x.tens(2, 3);`, ast.CallBinding{Resolved: true, Receiver: true, Arguments: []ast.ArgumentBinding{
+			{Param: "a", Argument: 0, Element: -1},
+			{Param: "b", Argument: 1, Element: -1},
+			{Param: "c", Argument: 2, Element: -1},
+		}}},
+	}
+
+	for _, test := range tests {
+		if got := binding(t, tens+test.source); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%q: expected binding %+v, got %+v", test.source, test.expected, got)
+		}
+	}
+}
+
+func TestElementsOnlyKnownAtRuntimeKeepTheirType(t *testing.T) {
+	got := binding(t, tens+`let xs: Array<Union<int, string>> = [1, 2, 3]; tens(xs);`)
+
+	for _, argument := range got.Arguments {
+		if argument.Expected == nil || argument.Expected.ToString() != "int" {
+			t.Errorf("expected element %d to be checked against int at runtime, got %v", argument.Element, argument.Expected)
+		}
+	}
+
+	got = binding(t, tens+`let xs = [1, 2, 3]; tens(xs);`)
+
+	for _, argument := range got.Arguments {
+		if argument.Expected != nil {
+			t.Errorf("expected element %d to be checked before it runs, got %v", argument.Element, argument.Expected.ToString())
+		}
+	}
+}
+
+func TestSpreadRecordsAreCheckedInOrder(t *testing.T) {
+	for range 10 {
+		expect_errors(t, tens+`tens({ c: "x", a: "y", b: "z" });`,
+			"Mismatched argument (2). Expected int, got string",
+			"Mismatched argument (0). Expected int, got string",
+			"Mismatched argument (1). Expected int, got string",
+		)
+	}
+}
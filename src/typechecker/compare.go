@@ -2,6 +2,7 @@ package typechecker
 
 import (
 	"reflect"
+	"slices"
 
 	"github.com/lucaengelhard/lang/src/ast"
 )
@@ -10,6 +11,75 @@ func match(expected, input ast.Type) bool {
 	return exec_match_op(expected, input) // || exec_match_op(b, a)
 }
 
+// Returns the type that covers both a and b. Unset types are ignored, so an
+// expression that failed to check or an empty array doesn't change the type
+// of the others. If one of the types already covers the other one, it is used.
+func unify(a, b ast.Type) ast.Type {
+	if a.IsUnset() || match(b, a) && !b.IsUnset() {
+		return b
+	}
+
+	if b.IsUnset() || match(a, b) {
+		return a
+	}
+
+	members := []ast.Type{}
+	for _, t := range []ast.Type{a, b} {
+		if t.Name == ast.UNION {
+			members = append(members, t.Arguments...)
+		} else {
+			members = append(members, t)
+		}
+	}
+
+	unique := []ast.Type{}
+	for _, member := range members {
+		if !slices.ContainsFunc(unique, func(t ast.Type) bool { return match(t, member) && match(member, t) }) {
+			unique = append(unique, member)
+		}
+	}
+
+	return ast.Type{Name: ast.UNION, Arguments: unique}
+}
+
+// Unifies the elements of an array literal. Arrays containing only ints and
+// floats are arrays of floats, the interpreter widens the ints accordingly.
+func unify_elements(elements []ast.Type) ast.Type {
+	element := ast.CreateUnsetType()
+
+	for _, t := range elements {
+		element = unify(element, t)
+	}
+
+	integer, float := ast.CreateBaseType(ast.INTEGER), ast.CreateBaseType(ast.FLOAT)
+	if element.Is(ast.UNION) && len(element.Arguments) == 2 &&
+		slices.ContainsFunc(element.Arguments, func(t ast.Type) bool { return reflect.DeepEqual(t, integer) }) &&
+		slices.ContainsFunc(element.Arguments, func(t ast.Type) bool { return reflect.DeepEqual(t, float) }) {
+		return float
+	}
+
+	return element
+}
+
+// Array literals of ints can be declared as arrays of floats, the
+// interpreter widens their elements
+func widens(expected ast.Type, value ast.Expr, computed ast.Type) bool {
+	_, is_literal := value.(ast.ArrayInstantiationExpr)
+	integer, float := ast.CreateBaseType(ast.INTEGER), ast.CreateBaseType(ast.FLOAT)
+
+	return is_literal && expected.Is(ast.ARRAY) && reflect.DeepEqual(array_element(expected), float) &&
+		reflect.DeepEqual(array_element(computed), integer)
+}
+
+// Returns the element type of an array type, unset for empty arrays
+func array_element(array ast.Type) ast.Type {
+	if len(array.Arguments) != 1 {
+		return ast.CreateUnsetType()
+	}
+
+	return array.Arguments[0]
+}
+
 type match_op func(a, b ast.Type) bool
 
 var match_lookup = map[string]map[string]match_op{}
@@ -17,6 +87,10 @@ var match_lookup = map[string]map[string]match_op{}
 func exec_match_op(a, b ast.Type) bool {
 	op, exists := match_lookup[a.Name][b.Name]
 
+	if a.Name == ast.ANY {
+		return true
+	}
+
 	if a.Name == ast.UNION && b.Name != ast.UNION {
 		for _, union_type := range a.Arguments {
 			if match(union_type, b) {
@@ -43,17 +117,80 @@ func create_match_op(a, b string, op match_op) {
 }
 
 func createMatchLookup() {
-	create_match_op(ast.DICT, ast.STRUCT, match_dict_struct)
+	create_match_op(ast.DICT, ast.STRUCT, match_properties)
+	create_match_op(ast.DICT, ast.DICT, match_properties)
+	create_match_op(ast.FUNCTION, ast.FUNCTION, match_function)
+	create_match_op(ast.ARRAY, ast.ARRAY, match_array)
+	create_match_op(ast.MUTABLE, ast.MUTABLE, match_mutable)
+	create_match_op(ast.UNION, ast.UNION, match_union)
 }
 
-func match_dict_struct(input_dict, input_struct ast.Type) bool {
+// Unions are sets, every member of the input needs to match a member of the
+// expected union, no matter in which order
+func match_union(expected, input ast.Type) bool {
+	for _, member := range input.Arguments {
+		if !slices.ContainsFunc(expected.Arguments, func(t ast.Type) bool { return match(t, member) }) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func match_mutable(expected, input ast.Type) bool {
+	return match(expected.Arguments[0], input.Arguments[0])
+}
+
+// Empty arrays match arrays of every element type
+func match_array(expected, input ast.Type) bool {
+	input_element := array_element(input)
+	return input_element.IsUnset() || match(array_element(expected), input_element)
+}
+
+// Functions match if their arguments and return types do. The names of the
+// arguments need to match as well, function values can be called with named
+// arguments.
+func match_function(expected, input ast.Type) bool {
+	if len(expected.Arguments) != len(input.Arguments) {
+		return false
+	}
+
+	for i, expected_part := range expected.Arguments {
+		input_part := input.Arguments[i]
+
+		if expected_part.Name != input_part.Name || len(expected_part.Arguments) != len(input_part.Arguments) {
+			return false
+		}
+
+		for j, expected_arg := range expected_part.Arguments {
+			input_arg := input_part.Arguments[j]
+
+			if expected_part.Name == ast.FUNCTION_ARG {
+				if expected_arg.Name != input_arg.Name {
+					return false
+				}
+
+				expected_arg, input_arg = expected_arg.Arguments[0], input_arg.Arguments[0]
+			}
+
+			if !match(expected_arg, input_arg) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
 
+// Interfaces are matched structurally, the input needs to have every
+// property of the interface but can have more
+func match_properties(input_dict, input_struct ast.Type) bool {
 	for _, dict_prop := range input_dict.Arguments {
 		var exists = false
 
 		for _, struct_prop := range input_struct.Arguments {
 			if dict_prop.Name == struct_prop.Name {
-				exists = match(dict_prop, struct_prop)
+				exists = match(dict_prop.Arguments[0], struct_prop.Arguments[0])
 			}
 		}
 
@@ -0,0 +1,67 @@
+package typechecker
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/lucaengelhard/lang/src/ast"
+)
+
+// Enums are typed like structs, every member is a property with the type of its value
+func enum_handler(node ast.EnumStmt, env *env) ast.Type {
+	members := make([]ast.Type, 0, len(node.Elements))
+
+	for _, element := range node.Elements {
+		members = append(members, wrap_property_type(element.Name, check(element.Value, env)))
+	}
+
+	env.set_type(node.Position, node.Identifier, ast.Type{Name: ast.ENUM, Arguments: members})
+	return ast.CreateUnsetType()
+}
+
+// Returns the type of the enum an expression refers to. Variables shadow enums of the same name.
+func (env *env) enum_type(expr ast.Expr) (ast.Type, bool) {
+	symbol, is_symbol := expr.(ast.SymbolExpr)
+
+	if !is_symbol || symbol.IsReference {
+		return ast.Type{}, false
+	}
+
+	if _, err := env.get(symbol.Value); err == nil {
+		return ast.Type{}, false
+	}
+
+	t, exists := env.get_root().Types[symbol.Value]
+	return t.Value, exists && t.Value.Is(ast.ENUM)
+}
+
+// Members of an enum have the type of the enum
+func enum_member_handler(node ast.ChainExpr, enum ast.Type, env *env) ast.Type {
+	name := ast.ExprName(node.Assignee)
+
+	if !slices.ContainsFunc(enum.Arguments, func(member ast.Type) bool { return member.Name == node.Member }) {
+		env.err(node.Position, fmt.Sprintf("Member %s doesn't exist on enum %s", node.Member, name), env.type_declared_here(name)...)
+		return ast.CreateUnsetType()
+	}
+
+	return enum
+}
+
+// Calling an enum with a value returns the name of the member with that value
+func enum_lookup_handler(node ast.FnCallExpr, enum ast.Type, env *env) ast.Type {
+	name := ast.ExprName(node.Caller)
+
+	if len(node.Arguments) != 1 {
+		env.err(node.Position, fmt.Sprintf("Looking up a member of %s takes exactly one value, got %d", name, len(node.Arguments)))
+		return ast.CreateBaseType(ast.STRING)
+	}
+
+	computed := check(node.Arguments[0].Value, env)
+	matches_member := slices.ContainsFunc(enum.Arguments, func(member ast.Type) bool { return match(member.Arguments[0], computed) })
+
+	if !computed.IsUnset() && !matches_member && !match(enum, computed) {
+		env.err(node.Arguments[0].Position, fmt.Sprintf("Enum %s has no members of type %s", name, computed.ToString()), env.type_declared_here(name)...)
+	}
+
+	return ast.CreateBaseType(ast.STRING)
+}
@@ -2,19 +2,47 @@ package typechecker
 
 import (
 	"fmt"
+	"slices"
 
 	"github.com/lucaengelhard/lang/src/ast"
+	"github.com/lucaengelhard/lang/src/errorhandling"
 )
 
 type env_decl struct {
 	Identifier string
 	Value      ast.Type
+	// Where the variable was declared, unset for builtins
+	Position ast.Position
+	// Input of the checker the variable was declared in
+	input int
+}
+
+type env_type struct {
+	Value    ast.Type
+	Position ast.Position
+	input    int
 }
 
 type env struct {
 	Declarations map[string]*env_decl
 	Parent       *env
-	Types        map[string]ast.Type
+	Types        map[string]env_type
+	checker      *Checker
+	// Labels of the loops around the scope inside the current function, "" for unlabeled loops
+	loops []string
+	// The function around the scope, nil outside of functions
+	function *function_scope
+}
+
+type function_scope struct {
+	// The return type written in the declaration, unset if it is inferred
+	declared ast.Type
+	// The types of all return statements unified, the inferred return type
+	returned ast.Type
+}
+
+func (env *env) err(pos ast.Position, message string, labels ...errorhandling.Label) {
+	env.checker.err(pos, message, labels...)
 }
 
 func (env *env) get(identifier string) (*env_decl, error) {
@@ -25,25 +53,27 @@ func (env *env) get(identifier string) (*env_decl, error) {
 	}
 
 	if !exist && env.Parent == nil {
-		return &env_decl{}, fmt.Errorf("Variable %s doesn't exist\n", identifier)
+		return &env_decl{}, fmt.Errorf("Variable %s doesn't exist", identifier)
 	}
 
 	return env.Parent.get(identifier)
 }
 
-func (env *env) set(identifer string, value ast.Type, isNew bool) error {
-	if isNew {
-		if _, exists := env.Declarations[identifer]; exists {
-			panic(fmt.Sprintf("%s already exists in scope\n", identifer))
-		}
+func (env *env) declare(pos ast.Position, identifer string, value ast.Type) error {
+	if _, exists := env.Declarations[identifer]; exists {
+		return fmt.Errorf("%s already exists in scope", identifer)
+	}
 
-		env.Declarations[identifer] = &env_decl{
-			Identifier: identifer,
-			Value:      value,
-		}
-		return nil
+	env.Declarations[identifer] = &env_decl{
+		Identifier: identifer,
+		Value:      value,
+		Position:   pos,
+		input:      env.checker.input,
 	}
+	return nil
+}
 
+func (env *env) set(identifer string, value ast.Type) error {
 	decl, err := env.get(identifer)
 
 	if err != nil {
@@ -54,14 +84,14 @@ func (env *env) set(identifer string, value ast.Type, isNew bool) error {
 
 	if !stripped_value.Is(ast.MUTABLE) {
 		if decl.Value.Is(ast.REFERENCE) {
-			return fmt.Errorf("%s is a reference to an immutable value\n", identifer)
+			return fmt.Errorf("%s is a reference to an immutable value", identifer)
 		}
 
-		return fmt.Errorf("%s is not mutable\n", identifer)
+		return fmt.Errorf("%s is not mutable", identifer)
 	}
 
 	if !match(stripped_value, value) {
-		return fmt.Errorf("Type %s is not assignable to variable of type %s\n", value.ToString(), stripped_value.ToString())
+		return fmt.Errorf("Type %s is not assignable to variable of type %s", value.ToString(), stripped_value.ToString())
 	}
 	return nil
 }
@@ -74,20 +104,20 @@ func (env *env) get_root() *env {
 	return env.Parent.get_root()
 }
 
-func (env *env) set_type(identifer string, t ast.Type) {
+func (env *env) set_type(pos ast.Position, identifer string, t ast.Type) {
 	root := env.get_root()
 
-	_, exists := root.Types[identifer]
+	existing, exists := root.Types[identifer]
 
 	if exists {
-		set_err(ast.Position{}, fmt.Sprintf("Type %s already exists", identifer))
+		env.err(pos, fmt.Sprintf("Type %s already exists", identifer), env.label(existing.input, existing.Position, "previously declared here")...)
 		return
 	}
 
-	root.Types[identifer] = t
+	root.Types[identifer] = env_type{Value: t, Position: pos, input: env.checker.input}
 }
 
-func (env *env) get_type(identifer string) ast.Type {
+func (env *env) get_type(pos ast.Position, identifer string) ast.Type {
 	root := env.get_root()
 
 	//TODO: What should happen with preset types?
@@ -95,23 +125,101 @@ func (env *env) get_type(identifer string) ast.Type {
 	t, exists := root.Types[identifer]
 
 	if !exists {
-		set_err(ast.Position{}, fmt.Sprintf("Type %s doesn't exist", identifer))
+		env.err(pos, fmt.Sprintf("Type %s doesn't exist", identifer))
 		return ast.CreateUnsetType()
 	}
 
-	return t
+	return t.Value
 }
 
-func createEnv(parent *env) *env {
-	var types map[string]ast.Type
+// Returns a label pointing at the declaration of a variable if it has one
+func (env *env) declared_here(identifier string) []errorhandling.Label {
+	decl, err := env.get(identifier)
+
+	if err != nil || decl.Position == (ast.Position{}) {
+		return nil
+	}
+
+	return env.label(decl.input, decl.Position, fmt.Sprintf("%s declared here as %s", identifier, decl.Value.ToString()))
+}
 
-	if parent == nil {
-		types = map[string]ast.Type{}
+// Positions are offsets into the source of one input, labels pointing at
+// earlier inputs (e.g. in the repl) are dropped
+func (env *env) label(input int, pos ast.Position, message string) []errorhandling.Label {
+	if input != env.checker.input {
+		return nil
 	}
 
+	return []errorhandling.Label{{Position: pos.Start, End: pos.End, Message: message}}
+}
+
+// Returns a label pointing at the declaration of a named type if it has one
+func (env *env) type_declared_here(name string) []errorhandling.Label {
+	t, exists := env.get_root().Types[name]
+
+	if !exists {
+		return nil
+	}
+
+	return env.label(t.input, t.Position, fmt.Sprintf("type %s declared here", name))
+}
+
+var base_types = []string{ast.INTEGER, ast.FLOAT, ast.BOOL, ast.STRING, ast.ANY}
+var generic_types = []string{ast.REFERENCE, ast.MUTABLE, ast.ARRAY, ast.UNION, ast.FUNCTION_RETURN}
+
+// Resolves a type as written in the source to the type it refers to.
+// Errors are reported at pos, the node the type was written in.
+func (env *env) resolve_type(pos ast.Position, t ast.Type) ast.Type {
+	if t.IsUnset() || slices.Contains(base_types, t.Name) {
+		return t
+	}
+
+	// Arguments of function types are named, only the types inside of them are resolved
+	if t.Name == ast.FUNCTION {
+		parts := make([]ast.Type, 0, len(t.Arguments))
+		for _, part := range t.Arguments {
+			if part.Name != ast.FUNCTION_ARG {
+				parts = append(parts, env.resolve_type(pos, part))
+				continue
+			}
+
+			args := make([]ast.Type, 0, len(part.Arguments))
+			for _, arg := range part.Arguments {
+				args = append(args, wrap_property_type(arg.Name, env.resolve_type(pos, arg.Arguments[0])))
+			}
+
+			parts = append(parts, ast.Type{Name: part.Name, Arguments: args})
+		}
+
+		return ast.Type{Name: t.Name, Arguments: parts}
+	}
+
+	if slices.Contains(generic_types, t.Name) {
+		arguments := make([]ast.Type, 0, len(t.Arguments))
+		for _, arg := range t.Arguments {
+			arguments = append(arguments, env.resolve_type(pos, arg))
+		}
+
+		return ast.Type{Name: t.Name, Arguments: arguments}
+	}
+
+	return env.get_type(pos, t.Name)
+}
+
+func createEnv(parent *env) *env {
 	return &env{
 		Parent:       parent,
 		Declarations: map[string]*env_decl{},
-		Types:        types,
+		checker:      parent.checker,
+		loops:        parent.loops,
+		function:     parent.function,
+	}
+}
+
+func createRootEnv(checker *Checker) *env {
+	return &env{
+		Declarations: map[string]*env_decl{},
+		Types:        map[string]env_type{},
+		checker:      checker,
 	}
 }
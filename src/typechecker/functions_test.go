@@ -0,0 +1,26 @@
+package typechecker_test
+
+import "testing"
+
+func TestEveryReturnIsChecked(t *testing.T) {
+	expect_errors(t, `fn f(c: bool) -> int { if (c) { return "s"; } return 1; }`, "Type string doesn't match the return type int")
+	expect_errors(t, `fn f() -> int { return "s"; return 1; }`, "Type string doesn't match the return type int")
+	expect_errors(t, `fn f(c: bool) -> int { if (c) { return 1; } else if (c == false) { return 2; } else { return true; } }`, "Type bool doesn't match the return type int")
+}
+
+func TestEveryPathReturns(t *testing.T) {
+	expect_errors(t, `fn f(c: bool) -> int { if (c) { return 1; } }`, "Not every path returns a value, expected int")
+	expect_errors(t, `fn f(c: bool) -> int { if (c) { return 1; } else { println(c); } }`, "Not every path returns a value, expected int")
+	expect_errors(t, `fn f(c: bool) -> int { if (c) { return 1; } else { return 2; } }`)
+	expect_errors(t, `fn f(c: bool) -> int { if (c) { println(c); } return 2; }`)
+}
+
+func TestInferredReturnTypes(t *testing.T) {
+	expect_errors(t, `fn f(c: bool) { if (c) { return 1; } return 2; } let a: int = f(true);`)
+	expect_errors(t, `fn f(c: bool) { if (c) { return 1; } return "a"; } let a: int = f(true);`, "Type Union<int, string> doesn't match int (int)")
+}
+
+func TestReturnsOfNestedFunctions(t *testing.T) {
+	expect_errors(t, `fn f() -> int { let g = fn () -> string { return "s"; }; return 1; }`)
+	expect_errors(t, `fn f() -> int { let g = fn () -> string { return 1; }; return 1; }`, "Type int doesn't match the return type string")
+}
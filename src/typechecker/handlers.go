@@ -2,9 +2,12 @@ package typechecker
 
 import (
 	"fmt"
+	"maps"
 	"reflect"
+	"slices"
 
 	"github.com/lucaengelhard/lang/src/ast"
+	"github.com/lucaengelhard/lang/src/errorhandling"
 	"github.com/lucaengelhard/lang/src/lexer"
 )
 
@@ -16,6 +19,7 @@ func createHandlerLookup() {
 	add_handler(float_handler)
 	add_handler(bool_handler)
 	add_handler(string_handler)
+	add_handler(string_template_handler)
 	add_handler(binary_expr_handler)
 	add_handler(declaration_handler)
 	add_handler(assignment_handler)
@@ -23,11 +27,28 @@ func createHandlerLookup() {
 	add_handler(interface_handler)
 	add_handler(struct_stmt_handler)
 	add_handler(struct_instantiation_handler)
+	add_handler(record_instantiation_handler)
+	add_handler(member_handler)
+	add_handler(enum_handler)
+	add_handler(index_handler)
+	add_handler(slice_handler)
 	add_handler(fn_declare_handler)
 	add_handler(return_handler)
 	add_handler(if_handler)
+	add_handler(if_expr_handler)
+	add_handler(switch_stmt_handler)
+	add_handler(switch_expr_handler)
+	add_handler(prefix_handler)
+	add_handler(for_handler)
+	add_handler(for_in_handler)
+	add_handler(while_handler)
+	add_handler(break_handler)
+	add_handler(continue_handler)
 	add_handler(fn_call_handler)
+	add_handler(checked_receiver_handler)
 	add_handler(deref_handler)
+	add_handler(bad_expr_handler)
+	add_handler(bad_stmt_handler)
 }
 
 type handler func(node any, env *env) ast.Type
@@ -39,7 +60,7 @@ func add_handler[Node any](handler func(node Node, env *env) ast.Type) {
 	_, exists := node_handler_lu[reflect.TypeFor[Node]()]
 
 	if exists {
-		set_err(ast.Position{}, fmt.Sprintf("Node handler already exists for node of type %s", reflect.TypeFor[Node]()))
+		panic(fmt.Sprintf("Node handler already exists for node of type %s", reflect.TypeFor[Node]()))
 	}
 
 	node_handler_lu[reflect.TypeFor[Node]()] = func(node any, env *env) ast.Type {
@@ -55,10 +76,8 @@ func block_handler(node ast.BlockStmt, env *env) ast.Type {
 	scope := createEnv(env)
 	var return_type = ast.CreateUnsetType()
 	for _, stmt := range node.Body {
-		_, isReturn := stmt.(ast.ReturnStmt)
-		_, isIf := stmt.(ast.IfStmt)
 		computed := check(stmt, scope)
-		if isReturn || isIf {
+		if returns_value(stmt) {
 			return_type = computed
 		}
 	}
@@ -66,11 +85,23 @@ func block_handler(node ast.BlockStmt, env *env) ast.Type {
 	return return_type
 }
 
+// Statements that can return from the function around them. Their type is
+// only set if they return on every path, the returned values themselves are
+// checked by return_handler.
+func returns_value(stmt ast.Stmt) bool {
+	switch stmt.(type) {
+	case ast.ReturnStmt, ast.IfStmt, ast.SwitchStmt, ast.ForStmt, ast.ForInStmt, ast.WhileStmt:
+		return true
+	}
+
+	return false
+}
+
 func symbol_handler(node ast.SymbolExpr, env *env) ast.Type {
 	val, err := env.get(node.Value)
 
 	if err != nil {
-		set_err(node.Position, err.Error())
+		env.err(node.Position, err.Error())
 		return ast.CreateUnsetType()
 	}
 
@@ -97,11 +128,44 @@ func string_handler(node ast.StringExpr, env *env) ast.Type {
 	return ast.CreateBaseType(ast.STRING)
 }
 
+func string_template_handler(node ast.StringTemplateExpr, env *env) ast.Type {
+	for _, expr := range node.Expressions {
+		errors := len(env.checker.errors)
+		t := check(expr, env)
+
+		// Unset types that didn't come from an error are values that don't exist
+		if t.IsUnset() && len(env.checker.errors) == errors {
+			env.err(expr.Pos(), "Can't embed an expression without a value in a string")
+		}
+	}
+
+	return ast.CreateBaseType(ast.STRING)
+}
+
 func binary_expr_handler(node ast.BinaryExpr, env *env) ast.Type {
 	value, err := exec_type_op(node.Operator.Kind, check(node.Left, env), check(node.Right, env))
 
 	if err != nil {
-		set_err(node.Position, err.Error())
+		env.err(node.Position, err.Error())
+		return ast.CreateUnsetType()
+	}
+
+	return value
+}
+
+// -x is evaluated as -1 * x
+func prefix_handler(node ast.PrefixExpr, env *env) ast.Type {
+	right := check(node.Right, env)
+
+	if node.Operator.Kind != lexer.MINUS {
+		env.err(node.Position, fmt.Sprintf("Unknown prefix operator %s", node.Operator.Literal))
+		return ast.CreateUnsetType()
+	}
+
+	value, err := exec_type_op(lexer.STAR, ast.CreateBaseType(ast.INTEGER), right)
+
+	if err != nil {
+		env.err(node.Position, err.Error())
 		return ast.CreateUnsetType()
 	}
 
@@ -110,36 +174,63 @@ func binary_expr_handler(node ast.BinaryExpr, env *env) ast.Type {
 
 func declaration_handler(node ast.DeclarationStmt, env *env) ast.Type {
 	computed := check(node.AssignedValue, env).Strip(ast.MUTABLE)
-	var assigned_type = ast.CreateUnsetType()
+	var assigned_type = computed
 
 	// TODO: make more sophisticated equality check, so that order of array doesn't matter for example
 	// Also partial matching doesn't work
-	if !node.Type.IsUnset() && !match(env.get_type(node.Type.Name), computed) {
-		set_err(node.Position, fmt.Sprintf("Type %s doesn't match %s (%s)", computed.ToString(), node.Type.ToString(), env.get_type(node.Type.Name).ToString()))
-		return ast.CreateUnsetType()
-	}
+	if !node.Type.IsUnset() {
+		explicit_type := env.resolve_type(node.Position, node.Type.Strip(ast.MUTABLE))
 
-	if node.Type.IsUnset() {
-		assigned_type = computed
-	} else {
-		assigned_type = env.get_type(node.Type.Name)
+		if widens(node.Type.Strip(ast.MUTABLE), node.AssignedValue, computed) {
+			computed = explicit_type
+		}
+
+		if !match(explicit_type, computed) {
+			value_pos := node.AssignedValue.Pos()
+			labels := append([]errorhandling.Label{{
+				Position: value_pos.Start,
+				End:      value_pos.End,
+				Message:  fmt.Sprintf("this is %s", computed.ToString()),
+			}}, env.type_declared_here(node.Type.Strip(ast.MUTABLE).Name)...)
+
+			env.err(node.Position, fmt.Sprintf("Type %s doesn't match %s (%s)", computed.ToString(), node.Type.Strip(ast.MUTABLE).ToString(), explicit_type.ToString()), labels...)
+			return ast.CreateUnsetType()
+		}
+
+		assigned_type = explicit_type
+	} else if computed.Is(ast.ARRAY) && array_element(computed).IsUnset() {
+		env.err(node.Position, "The type of an empty array can't be inferred, add a type annotation")
 	}
 
 	if node.IsMutable {
 		assigned_type = assigned_type.Mutable()
 	}
 
-	env.set(node.Identifier, assigned_type, true)
+	if err := env.declare(node.Position, node.Identifier, assigned_type); err != nil {
+		env.err(node.Position, err.Error(), env.declared_here(node.Identifier)...)
+	}
 
 	return ast.CreateUnsetType()
 }
 
 func assignment_handler(node ast.AssignmentExpr, env *env) ast.Type {
-	assignee := node.Assignee.(ast.SymbolExpr)
+	switch assignee := node.Assignee.(type) {
+	case ast.SymbolExpr:
+		return variable_assignment(node, assignee, env)
+	case ast.ChainExpr, ast.IndexExpr:
+		return element_assignment(node, assignee, env)
+	}
+
+	check(node.Right, env)
+	env.err(node.Assignee.Pos(), "Can't assign to this expression")
+	return ast.CreateUnsetType()
+}
+
+func variable_assignment(node ast.AssignmentExpr, assignee ast.SymbolExpr, env *env) ast.Type {
 	current_declaration, err := env.get(assignee.Value)
 
 	if err != nil {
-		set_err(node.Position, err.Error())
+		env.err(node.Position, err.Error())
 		return ast.CreateUnsetType()
 	}
 
@@ -153,50 +244,101 @@ func assignment_handler(node ast.AssignmentExpr, env *env) ast.Type {
 		computed, err := exec_type_op(op_token, stripped_current, right)
 
 		if err != nil {
-			set_err(node.Position, fmt.Sprintf("Type %s is not assignable to variable of type %s (%s)", right.ToString(), stripped_current.ToString(), err.Error()))
+			env.err(node.Position, fmt.Sprintf("Type %s is not assignable to variable of type %s (%s)", right.ToString(), stripped_current.ToString(), err.Error()), env.declared_here(assignee.Value)...)
 			return ast.CreateUnsetType()
 		}
 
-		err = env.set(assignee.Value, computed.Mutable(), false)
+		err = env.set(assignee.Value, computed.Mutable())
 
 		if err != nil {
-			set_err(node.Position, err.Error())
+			env.err(node.Position, err.Error(), env.declared_here(assignee.Value)...)
 		}
 	} else if node.Operator.Kind == lexer.ASSIGNMENT {
-		err = env.set(assignee.Value, right.Mutable(), false)
+		err = env.set(assignee.Value, right.Mutable())
 
 		if err != nil {
-			set_err(node.Position, err.Error())
+			env.err(node.Position, err.Error(), env.declared_here(assignee.Value)...)
 		}
 	} else {
-		set_err(node.Position, fmt.Sprintf("Unknown assignment operator: %s", node.Operator.Kind.ToString()))
+		env.err(node.Position, fmt.Sprintf("Unknown assignment operator: %s", node.Operator.Kind.ToString()))
 	}
 
 	return ast.CreateUnsetType()
 }
 
+// Arrays have a single element type. The element type of an empty array is
+// unset, it matches every array type and gets its type from the context.
 func array_instantiation_handler(node ast.ArrayInstantiationExpr, env *env) ast.Type {
-	elements := make([]ast.Type, 0)
+	elements := make([]ast.Type, 0, len(node.Elements))
 
 	for _, el := range node.Elements {
-		computed := check(el, env)
-		var exists = false
+		elements = append(elements, check(el, env))
+	}
 
-		for _, already_existing := range elements {
-			if reflect.DeepEqual(already_existing, computed) {
-				exists = true
-			}
+	return unify_elements(elements).Wrap(ast.ARRAY)
+}
+
+// Members and elements can only be assigned through a mutable variable holding the struct or array
+func element_assignment(node ast.AssignmentExpr, assignee ast.Expr, env *env) ast.Type {
+	right := check(node.Right, env).Strip(ast.MUTABLE)
+	root, _, ok := ast.AccessPath(assignee)
+
+	if !ok {
+		env.err(node.Assignee.Pos(), "Only members and elements of variables can be assigned")
+		return ast.CreateUnsetType()
+	}
+
+	target := ast.ExprName(assignee)
+	if target == "" {
+		target = "an element of " + root.Value
+	}
+
+	var current ast.Type
+
+	if indexed, is_index := assignee.(ast.IndexExpr); is_index {
+		container := check(indexed.Assignee, env)
+
+		if container.Strip(ast.REFERENCE).Strip(ast.MUTABLE).Is(ast.STRING) {
+			env.err(node.Position, "Strings can't be changed, characters can't be assigned")
+			return ast.CreateUnsetType()
 		}
 
-		if !exists {
-			elements = append(elements, computed)
+		current = element_type(indexed, container, env).Strip(ast.MUTABLE)
+	} else {
+		current = check(assignee, env).Strip(ast.MUTABLE)
+	}
+
+	if current.IsUnset() {
+		return current
+	}
+
+	if declaration, err := env.get(root.Value); err == nil && !declaration.Value.Strip(ast.REFERENCE).Is(ast.MUTABLE) {
+		env.err(node.Position, fmt.Sprintf("Can't assign to %s, %s is not mutable", target, root.Value), env.declared_here(root.Value)...)
+		return ast.CreateUnsetType()
+	}
+
+	value := right
+	op_token, op_token_exists := lexer.Assignment_operation_lu[node.Operator.Kind]
+
+	if op_token_exists {
+		computed, err := exec_type_op(op_token, current, right)
+
+		if err != nil {
+			env.err(node.Position, err.Error())
+			return ast.CreateUnsetType()
 		}
+
+		value = computed
+	} else if node.Operator.Kind != lexer.ASSIGNMENT {
+		env.err(node.Position, fmt.Sprintf("Unknown assignment operator: %s", node.Operator.Kind.ToString()))
+		return ast.CreateUnsetType()
 	}
 
-	return ast.Type{
-		Name:      ast.ARRAY,
-		Arguments: elements,
+	if !match(current, value) {
+		env.err(node.Position, fmt.Sprintf("Type %s is not assignable to %s of type %s", value.ToString(), target, current.ToString()))
 	}
+
+	return ast.CreateUnsetType()
 }
 
 func wrap_property_type(identifer string, prop_type ast.Type) ast.Type {
@@ -205,15 +347,16 @@ func wrap_property_type(identifer string, prop_type ast.Type) ast.Type {
 
 func interface_handler(node ast.InterfaceStmt, env *env) ast.Type {
 	if !node.SingleType.IsUnset() {
-		env.set_type(node.Identifier, node.SingleType)
+		env.set_type(node.Position, node.Identifier, node.SingleType)
 	} else {
 		properties := make([]ast.Type, 0)
 
-		for _, prop := range node.StructType {
+		for _, name := range slices.Sorted(maps.Keys(node.StructType)) {
+			prop := node.StructType[name]
 			properties = append(properties, wrap_property_type(prop.Name, prop.Type))
 		}
 
-		env.set_type(node.Identifier, ast.Type{
+		env.set_type(node.Position, node.Identifier, ast.Type{
 			Name:      ast.DICT,
 			Arguments: properties,
 		})
@@ -225,11 +368,12 @@ func interface_handler(node ast.InterfaceStmt, env *env) ast.Type {
 func struct_stmt_handler(node ast.StructStmt, env *env) ast.Type {
 	properties := make([]ast.Type, 0)
 
-	for _, prop := range node.Properties {
-		properties = append(properties, wrap_property_type(prop.Name, prop.Type))
+	for _, name := range slices.Sorted(maps.Keys(node.Properties)) {
+		prop := node.Properties[name]
+		properties = append(properties, wrap_property_type(prop.Name, env.resolve_type(prop.Position, prop.Type)))
 	}
 
-	env.set_type(node.Identifier, ast.Type{
+	env.set_type(node.Position, node.Identifier, ast.Type{
 		Name:      ast.STRUCT,
 		Arguments: properties,
 	})
@@ -238,7 +382,7 @@ func struct_stmt_handler(node ast.StructStmt, env *env) ast.Type {
 }
 
 func struct_instantiation_handler(node ast.StructInstantiationExpr, env *env) ast.Type {
-	struct_type := env.get_type(node.StructIdentifier)
+	struct_type := env.get_type(node.Position, node.StructIdentifier)
 
 	if struct_type.IsUnset() {
 		return struct_type
@@ -248,38 +392,142 @@ func struct_instantiation_handler(node ast.StructInstantiationExpr, env *env) as
 		prop_val, exists := node.Properties[prop_type.Name]
 
 		if !exists {
-			set_err(node.Position, fmt.Sprintf("Property %s missing on struct", prop_type.Name))
+			env.err(node.Position, fmt.Sprintf("Property %s missing on struct", prop_type.Name))
 			return struct_type
 		}
 
 		computed := wrap_property_type(prop_type.Name, check(prop_val, env))
 
-		if !match(prop_type, computed) {
-			set_err(node.Position, fmt.Sprintf("Property %s expected %s but got %s", prop_type.Name, prop_type.ToString(), computed.ToString()))
+		if !match(prop_type.Arguments[0], computed.Arguments[0]) {
+			env.err(node.Position, fmt.Sprintf("Property %s expected %s but got %s", prop_type.Name, prop_type.ToString(), computed.ToString()))
 			return struct_type
 		}
 
 	}
 
+	for _, name := range slices.Sorted(maps.Keys(node.Properties)) {
+		if !slices.ContainsFunc(struct_type.Arguments, func(prop ast.Type) bool { return prop.Name == name }) {
+			env.err(node.Properties[name].Pos(), fmt.Sprintf("Property %s doesn't exist on struct %s", name, node.StructIdentifier))
+		}
+	}
+
 	return struct_type
 }
 
+// Records are typed like interfaces that have exactly their properties
+func record_instantiation_handler(node ast.RecordInstantiationExpr, env *env) ast.Type {
+	properties := make([]ast.Type, 0, len(node.Properties))
+
+	for _, prop := range node.Properties {
+		properties = append(properties, wrap_property_type(prop.Name, check(prop.Value, env).Strip(ast.MUTABLE)))
+	}
+
+	return ast.Type{Name: ast.DICT, Arguments: properties}
+}
+
+// Structs, interfaces and records are the only types with members
+func member_handler(node ast.ChainExpr, env *env) ast.Type {
+	if enum, is_enum := env.enum_type(node.Assignee); is_enum {
+		return enum_member_handler(node, enum, env)
+	}
+
+	assignee := check(node.Assignee, env)
+	stripped := assignee.Strip(ast.REFERENCE).Strip(ast.MUTABLE)
+
+	switch stripped.Name {
+	case ast.UNSET_TYPE, ast.ANY:
+		return stripped
+	case ast.STRUCT, ast.DICT:
+		for _, prop := range stripped.Arguments {
+			if prop.Name == node.Member {
+				return prop.Arguments[0]
+			}
+		}
+
+		env.err(node.Position, fmt.Sprintf("Property %s doesn't exist on %s", node.Member, stripped.ToString()))
+	default:
+		env.err(node.Position, fmt.Sprintf("Can't access property %s on %s", node.Member, stripped.ToString()))
+	}
+
+	return ast.CreateUnsetType()
+}
+
+// The receiver of a member call keeps the type it was checked with when the
+// call was resolved, so it isn't checked again as an argument
+type checked_receiver struct {
+	ast.Expr
+	computed ast.Type
+}
+
+func checked_receiver_handler(node checked_receiver, env *env) ast.Type {
+	return node.computed
+}
+
+// Calls of a.foo() call a function stored in the property foo of a. Without
+// such a property foo(a) is called, passing &a if foo takes a reference.
+func member_call(node ast.FnCallExpr, chain ast.ChainExpr, env *env) (ast.FnCallExpr, bool) {
+	if _, is_enum := env.enum_type(chain.Assignee); is_enum {
+		return node, false
+	}
+
+	computed := check(chain.Assignee, env)
+	receiver := computed.Strip(ast.REFERENCE).Strip(ast.MUTABLE)
+
+	if receiver.Is(ast.STRUCT) || receiver.Is(ast.DICT) {
+		if slices.ContainsFunc(receiver.Arguments, func(prop ast.Type) bool { return prop.Name == chain.Member }) {
+			return node, false
+		}
+	}
+
+	declaration, err := env.get(chain.Member)
+	if err != nil || !declaration.Value.Is(ast.FUNCTION) {
+		return node, false
+	}
+
+	argument := ast.FnCallArg{Value: checked_receiver{Expr: chain.Assignee, computed: computed}, Position: chain.Assignee.Pos()}
+
+	for _, type_arg := range declaration.Value.Arguments {
+		if type_arg.Name != ast.FUNCTION_ARG || len(type_arg.Arguments) == 0 || is_variadic(type_arg) {
+			continue
+		}
+
+		symbol, is_symbol := chain.Assignee.(ast.SymbolExpr)
+		if type_arg.Arguments[0].Arguments[0].Is(ast.REFERENCE) && is_symbol {
+			symbol.IsReference = true
+			argument.Value = checked_receiver{Expr: symbol, computed: computed.Wrap(ast.REFERENCE)}
+		}
+	}
+
+	return ast.FnCallExpr{
+		Caller:    ast.SymbolExpr{Value: chain.Member, Position: chain.Position},
+		Arguments: append([]ast.FnCallArg{argument}, node.Arguments...),
+		Binding:   node.Binding,
+		Position:  node.Position,
+	}, true
+}
+
 func fn_declare_handler(node ast.FnDeclareExpr, env *env) ast.Type {
-	args := make([]ast.Type, 0)
+	args := make([]ast.Type, len(node.Arguments))
 	scope := createEnv(env)
-	var return_type = node.ReturnType
+	// break and continue can't reach loops outside of the function
+	scope.loops = nil
+	var return_type = env.resolve_type(node.Position, node.ReturnType)
 
 	for _, arg := range node.Arguments {
-		args = append(args, wrap_property_type(arg.Identifier, arg.Type))
-		scope.set(arg.Identifier, arg.Type, true)
+		arg_type := env.resolve_type(arg.Position, arg.Type)
+		args[arg.ArgIndex] = wrap_property_type(arg.Identifier, arg_type)
+		scope.declare(arg.Position, arg.Identifier, arg_type)
 	}
 
-	computed_return_type := check(node.Body, scope)
+	scope.function = &function_scope{declared: return_type, returned: ast.CreateUnsetType()}
 
-	if !return_type.IsUnset() && !match(return_type, computed_return_type) {
-		set_err(node.Position, fmt.Sprintf("Type %s doesn't match %s (%s)", computed_return_type.ToString(), return_type.ToString(), env.get_type(return_type.Name).ToString()))
-	} else {
-		return_type = computed_return_type
+	// The body only has a type if every path through it returns
+	returns_on_every_path := !check(node.Body, scope).IsUnset()
+
+	if !return_type.IsUnset() && !returns_on_every_path {
+		env.err(node.Position, fmt.Sprintf("Not every path returns a value, expected %s", return_type.ToString()))
+	} else if return_type.IsUnset() && returns_on_every_path {
+		return_type = scope.function.returned
 	}
 
 	return ast.Type{
@@ -292,40 +540,55 @@ func fn_declare_handler(node ast.FnDeclareExpr, env *env) ast.Type {
 }
 
 func fn_call_handler(node ast.FnCallExpr, env *env) ast.Type {
-	caller, _ := node.Caller.(ast.SymbolExpr)
-	declaration, err := env.get(caller.Value)
+	name := ast.ExprName(node.Caller)
+	var fn_type ast.Type
 	var return_type = ast.CreateUnsetType()
 
-	if err != nil {
-		set_err(node.Position, fmt.Sprintf("%s not found", caller.Value))
-		return ast.CreateUnsetType()
+	if enum, is_enum := env.enum_type(node.Caller); is_enum {
+		return enum_lookup_handler(node, enum, env)
 	}
 
-	if declaration.Value.Name != ast.FUNCTION {
-		set_err(node.Position, fmt.Sprintf("%s not a function", caller.Value))
-		return ast.CreateUnsetType()
+	if chain, is_member := node.Caller.(ast.ChainExpr); is_member {
+		if call, is_function := member_call(node, chain, env); is_function {
+			return fn_call_handler(call, env)
+		}
 	}
 
-	for _, type_arg := range declaration.Value.Arguments {
-		if type_arg.Name == ast.FUNCTION_ARG {
-			if len(type_arg.Arguments) < len(node.Arguments) {
-				set_err(node.Position, fmt.Sprintf("Too many arguments. Expected %d, got %d", len(type_arg.Arguments), len(node.Arguments)))
+	if caller, is_symbol := node.Caller.(ast.SymbolExpr); is_symbol {
+		declaration, err := env.get(caller.Value)
 
-			}
+		if err != nil {
+			env.err(node.Position, fmt.Sprintf("%s not found", caller.Value))
+			return ast.CreateUnsetType()
+		}
 
-			if len(type_arg.Arguments) > len(node.Arguments) {
-				set_err(node.Position, fmt.Sprintf("Missing arguments. Expected %d, got %d", len(type_arg.Arguments), len(node.Arguments)))
-			}
+		fn_type = declaration.Value
+	} else {
+		fn_type = check(node.Caller, env)
+
+		if fn_type.IsUnset() {
+			return fn_type
+		}
+	}
+
+	if fn_type.Name != ast.FUNCTION {
+		env.err(node.Position, fmt.Sprintf("%s not a function", name))
+		return ast.CreateUnsetType()
+	}
+
+	for _, type_arg := range fn_type.Arguments {
+		if type_arg.Name == ast.FUNCTION_ARG && is_variadic(type_arg) {
+			expected := type_arg.Arguments[0].Arguments[0].Arguments[0]
 
-			// TODO: Handle named args
 			for index, arg := range node.Arguments {
-				expected := type_arg.Arguments[index].Arguments[0]
 				computed := check(arg.Value, env)
 
 				if !match(expected, computed) {
-					set_err(node.Position, fmt.Sprintf("Mismatched argument (%d). Expected %s, got %s", index, expected.ToString(), computed.ToString()))
+					env.err(arg.Position, fmt.Sprintf("Mismatched argument (%d). Expected %s, got %s", index, expected.ToString(), computed.ToString()), env.declared_here(name)...)
 				}
 			}
+		} else if type_arg.Name == ast.FUNCTION_ARG {
+			check_arguments(node, type_arg.Arguments, name, env)
 		}
 
 		if type_arg.Name == ast.FUNCTION_RETURN {
@@ -336,24 +599,75 @@ func fn_call_handler(node ast.FnCallExpr, env *env) ast.Type {
 	return return_type
 }
 
+func is_variadic(fn_args ast.Type) bool {
+	return len(fn_args.Arguments) == 1 && fn_args.Arguments[0].Arguments[0].Is(ast.VARIADIC)
+}
+
+// Every returned value is checked against the declared return type of the
+// function. Returned values are copies, returning a mutable variable doesn't
+// return a mutable value.
 func return_handler(node ast.ReturnStmt, env *env) ast.Type {
-	return check(node.Value, env)
+	computed := check(node.Value, env).Strip(ast.MUTABLE)
+	function := env.function
+
+	if function == nil {
+		return computed
+	}
+
+	if function.declared.IsUnset() {
+		function.returned = unify(function.returned, computed)
+		return computed
+	}
+
+	if !computed.IsUnset() && !match(function.declared, computed) {
+		env.err(node.Value.Pos(), fmt.Sprintf("Type %s doesn't match the return type %s", computed.ToString(), function.declared.ToString()))
+	}
+
+	return function.declared
 }
 
 func deref_handler(node ast.DerefExpr, env *env) ast.Type {
 	ref := check(node.Ref, env)
 
 	if ref.Name != ast.REFERENCE {
-		set_err(node.Position, fmt.Sprintf("Can't deference a variable that's not a reference (%s)", ref.ToString()))
+		env.err(node.Position, fmt.Sprintf("Can't deference a variable that's not a reference (%s)", ref.ToString()))
 		return ast.CreateUnsetType()
 	}
 
 	return ref.Arguments[0]
 }
 
+// The parser already reported why these nodes are bad, checking them would only add noise
+func bad_expr_handler(node ast.BadExpr, env *env) ast.Type {
+	return ast.CreateUnsetType()
+}
+
+func bad_stmt_handler(node ast.BadStmt, env *env) ast.Type {
+	return ast.CreateUnsetType()
+}
+
+// An if statement only returns on every path if both branches do, without
+// an else branch it can always be left without returning
 func if_handler(node ast.IfStmt, env *env) ast.Type {
-	true_return := check(node.True, env)
-	false_return := check(node.False, env)
+	check_condition(node.Condition, env)
+	true_type, false_type := check(node.True, env), check(node.False, env)
 
-	return ast.Type{Name: ast.UNION, Arguments: []ast.Type{true_return, false_return}}
+	if true_type.IsUnset() || false_type.IsUnset() {
+		return ast.CreateUnsetType()
+	}
+
+	return unify(true_type, false_type)
+}
+
+func if_expr_handler(node ast.IfExpr, env *env) ast.Type {
+	check_condition(node.Condition, env)
+	return unify(check(node.True, env), check(node.False, env))
+}
+
+func check_condition(condition ast.Expr, env *env) {
+	computed := check(condition, env)
+
+	if !computed.IsUnset() && !match(ast.CreateBaseType(ast.BOOL), computed) {
+		env.err(condition.Pos(), fmt.Sprintf("Condition must be of type bool, got %s", computed.ToString()))
+	}
 }
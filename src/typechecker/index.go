@@ -0,0 +1,78 @@
+package typechecker
+
+import (
+	"fmt"
+
+	"github.com/lucaengelhard/lang/src/ast"
+)
+
+func index_handler(node ast.IndexExpr, env *env) ast.Type {
+	return element_type(node, check(node.Assignee, env), env)
+}
+
+// Arrays and strings are indexed with ints, dicts and structs with the names of their properties
+func element_type(node ast.IndexExpr, container ast.Type, env *env) ast.Type {
+	container = container.Strip(ast.REFERENCE).Strip(ast.MUTABLE)
+	index := check(node.Index, env)
+	element := ast.CreateUnsetType()
+
+	switch container.Name {
+	case ast.UNSET_TYPE, ast.ANY:
+		return container
+	case ast.STRING:
+		check_index(node.Index.Pos(), ast.INTEGER, index, env)
+		return container
+	case ast.ARRAY:
+		check_index(node.Index.Pos(), ast.INTEGER, index, env)
+		return array_element(container)
+	case ast.DICT, ast.STRUCT:
+		check_index(node.Index.Pos(), ast.STRING, index, env)
+
+		// The type of a single property is known if its name is
+		if key, is_literal := node.Index.(ast.StringExpr); is_literal {
+			for _, prop := range container.Arguments {
+				if prop.Name == key.Value {
+					return prop.Arguments[0]
+				}
+			}
+
+			env.err(node.Index.Pos(), fmt.Sprintf("Property %s doesn't exist on %s", key.Value, container.ToString()))
+			return element
+		}
+
+		for _, prop := range container.Arguments {
+			element = unify(element, prop.Arguments[0])
+		}
+	default:
+		env.err(node.Position, fmt.Sprintf("Can't index a value of type %s", container.ToString()))
+	}
+
+	return element
+}
+
+func check_index(pos ast.Position, expected string, index ast.Type, env *env) {
+	index = index.Strip(ast.MUTABLE)
+
+	if !index.IsUnset() && !match(ast.CreateBaseType(expected), index) {
+		env.err(pos, fmt.Sprintf("Index must be of type %s, got %s", expected, index.ToString()))
+	}
+}
+
+// Slicing an array or a string results in a value of the same type
+func slice_handler(node ast.SliceExpr, env *env) ast.Type {
+	container := check(node.Assignee, env).Strip(ast.REFERENCE).Strip(ast.MUTABLE)
+
+	for _, bound := range []ast.Expr{node.Start, node.End} {
+		if bound != nil {
+			check_index(bound.Pos(), ast.INTEGER, check(bound, env), env)
+		}
+	}
+
+	switch container.Name {
+	case ast.UNSET_TYPE, ast.ANY, ast.STRING, ast.ARRAY:
+		return container
+	}
+
+	env.err(node.Position, fmt.Sprintf("Can't slice a value of type %s", container.ToString()))
+	return ast.CreateUnsetType()
+}
@@ -0,0 +1,103 @@
+package typechecker
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/lucaengelhard/lang/src/ast"
+)
+
+func loop_scope(label string, env *env) *env {
+	scope := createEnv(env)
+	scope.loops = append(slices.Clone(env.loops), label)
+	return scope
+}
+
+// The body of a loop might not run, so a loop never returns on every path
+func for_handler(node ast.ForStmt, env *env) ast.Type {
+	scope := loop_scope(node.Label, env)
+	check(node.Assignment, scope)
+
+	if condition, ok := node.Condition.(ast.ExpressionStmt); ok {
+		check_condition(condition.Expression, scope)
+	} else {
+		check(node.Condition, scope)
+	}
+
+	check(node.Increment, scope)
+	check(node.Body, scope)
+	return ast.CreateUnsetType()
+}
+
+func while_handler(node ast.WhileStmt, env *env) ast.Type {
+	scope := loop_scope(node.Label, env)
+	check_condition(node.Condition, scope)
+	check(node.Body, scope)
+	return ast.CreateUnsetType()
+}
+
+func for_in_handler(node ast.ForInStmt, env *env) ast.Type {
+	iterable := check(node.Iterable, env).Strip(ast.REFERENCE).Strip(ast.MUTABLE)
+	element, index := iteration_types(node.Iterable.Pos(), iterable, env)
+
+	scope := loop_scope(node.Label, env)
+	if err := scope.declare(node.Position, node.Element, element); err != nil {
+		env.err(node.Position, err.Error())
+	}
+
+	if node.Index != "" {
+		if err := scope.declare(node.Position, node.Index, index); err != nil {
+			env.err(node.Position, err.Error())
+		}
+	}
+
+	check(node.Body, scope)
+	return ast.CreateUnsetType()
+}
+
+// Returns the types of the elements and indices of an iterable: Array<T>
+// has elements of type T and int indices, Dict<key<T>, ...> has values
+// of the property types and string keys
+func iteration_types(pos ast.Position, iterable ast.Type, env *env) (ast.Type, ast.Type) {
+	element := ast.CreateUnsetType()
+
+	switch iterable.Name {
+	case ast.ARRAY:
+		return array_element(iterable), ast.CreateBaseType(ast.INTEGER)
+	case ast.DICT:
+		for _, prop := range iterable.Arguments {
+			element = unify(element, prop.Arguments[0])
+		}
+
+		return element, ast.CreateBaseType(ast.STRING)
+	case ast.ANY:
+		return iterable, iterable
+	}
+
+	if !iterable.IsUnset() {
+		env.err(pos, fmt.Sprintf("Can't iterate over a value of type %s", iterable.ToString()))
+	}
+
+	return ast.CreateUnsetType(), ast.CreateUnsetType()
+}
+
+func break_handler(node ast.BreakStmt, env *env) ast.Type {
+	check_loop_target("break", node.Label, node.Position, env)
+	return ast.CreateUnsetType()
+}
+
+func continue_handler(node ast.ContinueStmt, env *env) ast.Type {
+	check_loop_target("continue", node.Label, node.Position, env)
+	return ast.CreateUnsetType()
+}
+
+func check_loop_target(keyword string, label string, pos ast.Position, env *env) {
+	if len(env.loops) == 0 {
+		env.err(pos, fmt.Sprintf("%s outside of a loop", keyword))
+		return
+	}
+
+	if label != "" && !slices.Contains(env.loops, label) {
+		env.err(pos, fmt.Sprintf("No loop labeled %s around %s", label, keyword))
+	}
+}
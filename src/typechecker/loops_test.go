@@ -0,0 +1,38 @@
+package typechecker_test
+
+import "testing"
+
+func TestReturnsInLoopsAreChecked(t *testing.T) {
+	expect_errors(t, `fn f(xs: Array<int>) -> int { for (x in xs) { return "s"; } return 1; }`, "Type string doesn't match the return type int")
+	expect_errors(t, `fn f(c: bool) -> int { while (c) { return "s"; } return 1; }`, "Type string doesn't match the return type int")
+	expect_errors(t, `fn f() -> int { for (let mut i = 0; i < 3; i++) { return "s"; } return 1; }`, "Type string doesn't match the return type int")
+}
+
+func TestLoopsDontReturnOnEveryPath(t *testing.T) {
+	expect_errors(t, `fn f(c: bool) -> int { while (c) { return 1; } }`, "Not every path returns a value, expected int")
+}
+
+func TestIterationTypes(t *testing.T) {
+	expect_errors(t, `for (x, i in [1, 2]) { let a: int = x + i; }`)
+	expect_errors(t, `for (v, k in { a: 1 }) { let a: string = k; }`)
+	expect_errors(t, `for (x in 1) { }`, "Can't iterate over a value of type int")
+	expect_errors(t, `for (x, x in [1]) { }`, "x already exists in scope")
+}
+
+func TestBreakAndContinue(t *testing.T) {
+	expect_errors(t, `while (true) { break; }`)
+	expect_errors(t, `for (x in [1]) { continue; }`)
+	expect_errors(t, `outer: while (true) { for (x in [1]) { break outer; } }`)
+	expect_errors(t, `outer: while (true) { while (true) { continue outer; } }`)
+}
+
+func TestBreakAndContinueOutsideOfLoops(t *testing.T) {
+	expect_errors(t, `break;`, "break outside of a loop")
+	expect_errors(t, `if (true) { continue; }`, "continue outside of a loop")
+	expect_errors(t, `while (true) { let f = fn () { break; }; }`, "break outside of a loop")
+}
+
+func TestUnknownLoopLabels(t *testing.T) {
+	expect_errors(t, `while (true) { break outer; }`, "No loop labeled outer around break")
+	expect_errors(t, `outer: while (true) { } while (true) { continue outer; }`, "No loop labeled outer around continue")
+}
@@ -65,8 +65,36 @@ func create_commuative_type_binop(token lexer.TokenKind, a, b, return_type ast.T
 }
 
 func createOpLookup() {
-	create_type_binop(lexer.PLUS, ast.CreateBaseType(ast.INTEGER), ast.CreateBaseType(ast.INTEGER), ast.CreateBaseType(ast.INTEGER))
-	create_commuative_type_binop(lexer.PLUS, ast.CreateBaseType(ast.INTEGER), ast.CreateBaseType(ast.FLOAT), ast.CreateBaseType(ast.FLOAT))
-	create_type_binop(lexer.MINUS, ast.CreateBaseType(ast.INTEGER), ast.CreateBaseType(ast.INTEGER), ast.CreateBaseType(ast.INTEGER))
-	create_commuative_type_binop(lexer.MINUS, ast.CreateBaseType(ast.INTEGER), ast.CreateBaseType(ast.FLOAT), ast.CreateBaseType(ast.FLOAT))
+	integer := ast.CreateBaseType(ast.INTEGER)
+	float := ast.CreateBaseType(ast.FLOAT)
+	str := ast.CreateBaseType(ast.STRING)
+	boolean := ast.CreateBaseType(ast.BOOL)
+	enum := ast.CreateBaseType(ast.ENUM)
+
+	for _, token := range []lexer.TokenKind{lexer.PLUS, lexer.MINUS, lexer.STAR, lexer.SLASH} {
+		create_type_binop(token, integer, integer, integer)
+		create_type_binop(token, float, float, float)
+		create_commuative_type_binop(token, integer, float, float)
+	}
+	create_type_binop(lexer.PERCENT, integer, integer, integer)
+
+	create_type_binop(lexer.PLUS, str, str, str)
+	create_commuative_type_binop(lexer.PLUS, str, integer, str)
+
+	for _, token := range []lexer.TokenKind{lexer.LESS, lexer.LESS_EQUALS, lexer.GREATER, lexer.GREATER_EQUALS} {
+		create_type_binop(token, integer, integer, boolean)
+		create_type_binop(token, float, float, boolean)
+		create_commuative_type_binop(token, integer, float, boolean)
+	}
+
+	for _, token := range []lexer.TokenKind{lexer.EQUALS, lexer.NOT_EQUALS} {
+		create_type_binop(token, integer, integer, boolean)
+		create_type_binop(token, float, float, boolean)
+		create_type_binop(token, str, str, boolean)
+		create_type_binop(token, boolean, boolean, boolean)
+		create_type_binop(token, enum, enum, boolean)
+	}
+
+	create_type_binop(lexer.AND, boolean, boolean, boolean)
+	create_type_binop(lexer.OR, boolean, boolean, boolean)
 }
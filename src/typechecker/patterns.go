@@ -0,0 +1,146 @@
+package typechecker
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/lucaengelhard/lang/src/ast"
+)
+
+// Like if statements, the type of a switch statement is the type its blocks return
+func switch_stmt_handler(node ast.SwitchStmt, env *env) ast.Type {
+	return check_switch(node.Subject, node.Arms, false, env)
+}
+
+func switch_expr_handler(node ast.SwitchExpr, env *env) ast.Type {
+	return check_switch(node.Subject, node.Arms, true, env)
+}
+
+func check_switch(subject ast.Expr, arms []ast.SwitchArm, is_expr bool, env *env) ast.Type {
+	subject_type := check(subject, env).Strip(ast.MUTABLE)
+
+	// Type patterns narrow the subject if it's a variable
+	var narrowed string
+	if symbol, ok := subject.(ast.SymbolExpr); ok && !symbol.IsReference {
+		narrowed = symbol.Value
+	}
+
+	result := ast.CreateUnsetType()
+	// A switch statement only returns a value if every arm does and one of
+	// them matches every value
+	every_arm_returns, catches_all := true, false
+
+	for _, arm := range arms {
+		scope := createEnv(env)
+		check_pattern(arm.Pattern, subject_type, narrowed, scope)
+
+		if arm.Guard != nil {
+			check_condition(arm.Guard, scope)
+		}
+
+		computed := check(arm.Body, scope)
+		_, is_block := arm.Body.(ast.BlockStmt)
+
+		if is_expr || is_block {
+			result = unify(result, computed)
+		}
+
+		every_arm_returns = every_arm_returns && is_block && !computed.IsUnset()
+
+		if name, is_name := arm.Pattern.(ast.NamePattern); is_name && arm.Guard == nil && !is_type_name(name.Name, env) {
+			catches_all = true
+		}
+	}
+
+	if !is_expr && !(every_arm_returns && catches_all) {
+		return ast.CreateUnsetType()
+	}
+
+	return result
+}
+
+func is_type_name(name string, env *env) bool {
+	_, exists := env.get_root().Types[name]
+	return exists || slices.Contains(base_types, name)
+}
+
+// Reports patterns whose type can't overlap with the type of the matched value
+func check_overlap(pos ast.Position, pattern ast.Type, value ast.Type, env *env) bool {
+	if pattern.IsUnset() || value.IsUnset() || match(value, pattern) || match(pattern, value) {
+		return true
+	}
+
+	env.err(pos, fmt.Sprintf("Pattern of type %s can never match a value of type %s", pattern.ToString(), value.ToString()))
+	return false
+}
+
+// Declares the variable the subject is stored in with the narrower type
+// the pattern guarantees
+func narrow(pos ast.Position, name string, pattern ast.Type, value ast.Type, env *env) {
+	if name == "" || match(pattern, value) {
+		return
+	}
+
+	decl, err := env.get(name)
+	if err != nil {
+		return
+	}
+
+	if decl.Value.Is(ast.MUTABLE) {
+		pattern = pattern.Mutable()
+	}
+
+	env.declare(pos, name, pattern)
+}
+
+func check_pattern(pattern ast.Pattern, value ast.Type, narrowed string, env *env) {
+	switch pattern := pattern.(type) {
+	case ast.ValuePattern:
+		check_overlap(pattern.Position, check(pattern.Value, env), value, env)
+	case ast.ConditionPattern:
+		check_condition(pattern.Condition, env)
+	case ast.NamePattern:
+		if is_type_name(pattern.Name, env) {
+			pattern_type := env.resolve_type(pattern.Position, ast.CreateBaseType(pattern.Name))
+			if check_overlap(pattern.Position, pattern_type, value, env) {
+				narrow(pattern.Position, narrowed, pattern_type, value, env)
+			}
+			return
+		}
+
+		if err := env.declare(pattern.Position, pattern.Name, value); err != nil {
+			env.err(pattern.Position, err.Error(), env.declared_here(pattern.Name)...)
+		}
+	case ast.DestructurePattern:
+		check_destructure_pattern(pattern, value, narrowed, env)
+	}
+}
+
+func check_destructure_pattern(pattern ast.DestructurePattern, value ast.Type, narrowed string, env *env) {
+	pattern_type := env.get_type(pattern.Position, pattern.Type)
+
+	if pattern_type.IsUnset() {
+		return
+	}
+
+	if pattern_type.Name != ast.STRUCT && pattern_type.Name != ast.DICT {
+		env.err(pattern.Position, fmt.Sprintf("Only structs and interfaces can be destructured, %s is %s", pattern.Type, pattern_type.ToString()), env.type_declared_here(pattern.Type)...)
+		return
+	}
+
+	if check_overlap(pattern.Position, pattern_type, value, env) {
+		narrow(pattern.Position, narrowed, pattern_type, value, env)
+	}
+
+	for _, field := range pattern.Fields {
+		index := slices.IndexFunc(pattern_type.Arguments, func(prop ast.Type) bool { return prop.Name == field.Name })
+
+		if index < 0 {
+			env.err(field.Position, fmt.Sprintf("%s has no property %s", pattern.Type, field.Name), env.type_declared_here(pattern.Type)...)
+			continue
+		}
+
+		field_type := env.resolve_type(field.Position, pattern_type.Arguments[index].Arguments[0])
+		check_pattern(field.Pattern, field_type, "", env)
+	}
+}
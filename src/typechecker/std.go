@@ -0,0 +1,20 @@
+package typechecker
+
+import "github.com/lucaengelhard/lang/src/ast"
+
+func createStdEnv(checker *Checker) *env {
+	scope := createRootEnv(checker)
+	scope.declare(ast.Position{}, "print", std_variadic_fn())
+	scope.declare(ast.Position{}, "println", std_variadic_fn())
+	return scope
+}
+
+func std_variadic_fn() ast.Type {
+	return ast.Type{
+		Name: ast.FUNCTION,
+		Arguments: []ast.Type{
+			{Name: ast.FUNCTION_ARG, Arguments: []ast.Type{wrap_property_type("args", ast.CreateBaseType(ast.ANY).Wrap(ast.VARIADIC))}},
+			wrap_property_type(ast.FUNCTION_RETURN, ast.CreateUnsetType()),
+		},
+	}
+}
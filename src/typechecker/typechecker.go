@@ -2,30 +2,88 @@ package typechecker
 
 import (
 	"fmt"
+	"maps"
 	"reflect"
+	"sync"
 
 	"github.com/lucaengelhard/lang/src/ast"
 	"github.com/lucaengelhard/lang/src/errorhandling"
 	"github.com/sanity-io/litter"
 )
 
-var errors = make([]errorhandling.Error, 0)
+var lookups_once sync.Once
 
-func set_err(pos ast.Position, message string) {
-	errors = append(errors, errorhandling.Error{
-		Message:  "Type error -> " + message,
-		Position: pos.Start,
+// The lookups are only written once and can be shared by all checkers afterwards
+func createLookups() {
+	lookups_once.Do(func() {
+		createOpLookup()
+		createHandlerLookup()
+		createMatchLookup()
 	})
 }
 
 func Init(node ast.Stmt) []errorhandling.Error {
-	createOpLookup()
-	createHandlerLookup()
-	createMatchLookup()
+	_, errs := New().Check(node)
+	return errs
+}
+
+// Checker holds the state of a typechecking session. Several checkers can be
+// used concurrently, a single checker must not.
+type Checker struct {
+	root   *env
+	errors []errorhandling.Error
+	// Number of the source that is checked, declarations remember the input they were made in
+	input int
+}
+
+func New() *Checker {
+	createLookups()
+	checker := &Checker{errors: make([]errorhandling.Error, 0)}
+	checker.root = createStdEnv(checker)
+	return checker
+}
+
+// NextInput starts checking a new source. Errors no longer point at the
+// declarations made in earlier sources.
+func (c *Checker) NextInput() {
+	c.input++
+}
+
+// Snapshot holds the declarations of the root scope at one point in time
+type Snapshot struct {
+	declarations map[string]*env_decl
+	types        map[string]env_type
+}
+
+// Snapshot returns the current declarations, Restore goes back to them.
+// Declarations are never changed, only added, so the maps are copied shallowly.
+func (c *Checker) Snapshot() Snapshot {
+	return Snapshot{declarations: maps.Clone(c.root.Declarations), types: maps.Clone(c.root.Types)}
+}
+
+// Restore drops every declaration made after the snapshot was taken
+func (c *Checker) Restore(snapshot Snapshot) {
+	c.root.Declarations = maps.Clone(snapshot.declarations)
+	c.root.Types = maps.Clone(snapshot.types)
+}
 
-	root := createEnv(nil)
-	check(node, root)
-	return errors
+// Check typechecks a node directly inside of the root scope and returns its type
+// and the errors found in it. Declarations made by the node are kept for the
+// following checks.
+func (c *Checker) Check(node ast.Stmt) (ast.Type, []errorhandling.Error) {
+	c.errors = make([]errorhandling.Error, 0)
+	computed := check(node, c.root)
+	return computed, c.errors
+}
+
+func (c *Checker) err(pos ast.Position, message string, labels ...errorhandling.Label) {
+	c.errors = append(c.errors, errorhandling.Error{
+		Message:  message,
+		Phase:    errorhandling.TYPE,
+		Position: pos.Start,
+		End:      pos.End,
+		Labels:   labels,
+	})
 }
 
 func check(node any, env *env) ast.Type {
@@ -33,7 +91,11 @@ func check(node any, env *env) ast.Type {
 
 	if !exists {
 		litter.D(node)
-		set_err(ast.Position{}, fmt.Sprintf("Node %s unknown to typechecker :(", reflect.TypeOf(node)))
+		var pos ast.Position
+		if positioned, ok := node.(interface{ Pos() ast.Position }); ok {
+			pos = positioned.Pos()
+		}
+		env.err(pos, fmt.Sprintf("Node %s unknown to typechecker :(", reflect.TypeOf(node)))
 		return ast.CreateUnsetType()
 	}
 
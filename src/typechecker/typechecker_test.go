@@ -0,0 +1,92 @@
+package typechecker_test
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/lucaengelhard/lang/src/ast"
+	"github.com/lucaengelhard/lang/src/errorhandling"
+	"github.com/lucaengelhard/lang/src/lexer"
+	"github.com/lucaengelhard/lang/src/parser"
+	"github.com/lucaengelhard/lang/src/typechecker"
+)
+
+func parse(t *testing.T, source string) ast.Stmt {
+	t.Helper()
+	tokens, errors := lexer.Tokenize(source)
+
+	if len(errors) > 0 {
+		t.Fatalf("unexpected lexer error in %q: %s", source, errors[0].Message)
+	}
+
+	tree, errors := parser.Parse(tokens)
+
+	if len(errors) > 0 {
+		t.Fatalf("unexpected parser error in %q: %s", source, errors[0].Message)
+	}
+
+	return tree
+}
+
+func messages(errors []errorhandling.Error) []string {
+	result := make([]string, 0, len(errors))
+	for _, err := range errors {
+		result = append(result, err.Message)
+	}
+	return result
+}
+
+// Expects checking the source to report exactly the given errors in order
+func expect_errors(t *testing.T, source string, expected ...string) {
+	t.Helper()
+	_, errors := typechecker.New().Check(parse(t, source))
+
+	if !slices.Equal(messages(errors), expected) {
+		t.Errorf("checking %q: expected errors %q, got %q", source, expected, messages(errors))
+	}
+}
+
+// Checks the single statement of a source directly in the root scope of a checker
+func check_statement(t *testing.T, checker *typechecker.Checker, source string) []errorhandling.Error {
+	t.Helper()
+	_, errors := checker.Check(parse(t, source).(ast.BlockStmt).Body[0])
+	return errors
+}
+
+func TestCheckersAreIndependent(t *testing.T) {
+	first, second := typechecker.New(), typechecker.New()
+
+	if errors := check_statement(t, first, `let a = 1;`); len(errors) > 0 {
+		t.Fatalf("unexpected errors %q", messages(errors))
+	}
+
+	if errors := check_statement(t, second, `let b = a;`); !slices.Equal(messages(errors), []string{"Variable a doesn't exist"}) {
+		t.Errorf("expected declarations not to leak into another checker, got %q", messages(errors))
+	}
+
+	if errors := check_statement(t, first, `let b = a;`); len(errors) > 0 {
+		t.Errorf("expected declarations to persist between checks, got %q", messages(errors))
+	}
+}
+
+// Run with -race to find state shared between checkers
+func TestConcurrentCheckers(t *testing.T) {
+	var group sync.WaitGroup
+
+	for i := range 16 {
+		group.Go(func() {
+			source := fmt.Sprintf(`fn add(a: int, b: int) -> int { return a + b; } let x: int = add(%d, 1); let y: string = x;`, i)
+			tokens, _ := lexer.Tokenize(source)
+			tree, _ := parser.Parse(tokens)
+			_, errors := typechecker.New().Check(tree)
+
+			if len(errors) != 1 {
+				t.Errorf("expected one error, got %q", messages(errors))
+			}
+		})
+	}
+
+	group.Wait()
+}